@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	a "github.com/NYULibraries/aswa/pkg/application"
+	c "github.com/NYULibraries/aswa/pkg/config"
+)
+
+// AcceptDriftCommand is the first argument that runs DoAcceptDrift instead of the checks.
+const AcceptDriftCommand = c.CommandAcceptDrift
+
+// DoAcceptDrift forgets the drift baselines of the applications named in args, in the state file
+// at DRIFT_STATE_PATH, so the next passing check of each records its current page as the new
+// baseline.
+func DoAcceptDrift(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet(AcceptDriftCommand, flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() {
+		fmt.Fprintln(out, "Usage: aswa accept-drift application ...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no application given")
+	}
+
+	baselines, err := a.LoadDriftBaselines(c.GetDriftStatePath())
+	if err != nil {
+		return err
+	}
+	var unknown []string
+	for _, name := range flags.Args() {
+		if baselines.Forget(name) {
+			fmt.Fprintf(out, "%s: baseline removed, the next passing check records a new one\n", name)
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	if err := baselines.Save(); err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("no drift baseline recorded for: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	a "github.com/NYULibraries/aswa/pkg/application"
+	c "github.com/NYULibraries/aswa/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoAcceptDrift(t *testing.T) {
+	const state = `{
+  "baselines": {
+    "bess-vue": {"sha256": "9ac2", "size": 48390, "url": "https://cdn.library.nyu.edu/bess-vue/app.min.js"},
+    "libguides-assets": {"sha256": "3b1f", "size": 1000, "url": "https://cdn.library.nyu.edu/libguides/index.min.js"}
+  }
+}`
+
+	var tests = []struct {
+		description   string
+		args          []string
+		wantOutput    string
+		wantErr       string
+		wantRemaining []string
+	}{
+		{"Accepts one", []string{"bess-vue"},
+			"bess-vue: baseline removed, the next passing check records a new one\n", "", []string{"libguides-assets"}},
+		{"Unknown application", []string{"bess-vue", "primo-ve"},
+			"bess-vue: baseline removed, the next passing check records a new one\n", "no drift baseline recorded for: primo-ve", []string{"libguides-assets"}},
+		{"No application", nil, "Usage: aswa accept-drift", "no application given", []string{"bess-vue", "libguides-assets"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "drift_baselines.json")
+			require.NoError(t, os.WriteFile(path, []byte(state), 0o644))
+			t.Setenv(c.EnvDriftStatePath, path)
+
+			var out bytes.Buffer
+			err := DoAcceptDrift(test.args, &out)
+
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.wantErr)
+			}
+			assert.Contains(t, out.String(), test.wantOutput)
+			baselines, err := a.LoadDriftBaselines(path)
+			require.NoError(t, err)
+			var remaining []string
+			for name := range baselines.Baselines {
+				remaining = append(remaining, name)
+			}
+			assert.ElementsMatch(t, test.wantRemaining, remaining)
+		})
+	}
+}
@@ -38,29 +38,81 @@ func postToSlack(tests []FailingSyntheticTest) error {
 	return nil
 }
 
+// getStatus is the checkFunc used for real runs.
+func getStatus(app *a.Application) *a.AppCheckStatus {
+	return app.GetStatus()
+}
+
+// selectApplications returns the applications to check: all of them when targetAppName is empty,
+// otherwise only the first application with a matching name.
+func selectApplications(appData []*a.Application, targetAppName string) []*a.Application {
+	if targetAppName == "" {
+		return appData
+	}
+	for _, app := range appData {
+		if app.Name == targetAppName {
+			return []*a.Application{app}
+		}
+	}
+	return nil
+}
+
+// evaluateDrift compares the results of applications with a drift check against the baselines
+// in the state file at statePath, recording baselines for passing checks that have none yet. When the
+// state file cannot be read, or could not be saved, each drift check reports it instead, since every
+// run would otherwise record its baselines anew. The results are complete even when an error is
+// returned.
+func evaluateDrift(apps []*a.Application, results []*a.AppCheckStatus, statePath string) error {
+	usesDrift := false
+	for _, app := range apps {
+		usesDrift = usesDrift || app.Drift != nil
+	}
+	if !usesDrift {
+		return nil
+	}
+
+	baselines, err := a.LoadDriftBaselines(statePath)
+	if err == nil {
+		err = baselines.CheckWritable()
+	}
+	if err != nil {
+		for _, result := range results {
+			if result.Application != nil && result.Application.Drift != nil {
+				result.Drift = &a.DriftResult{Err: err.Error()}
+			}
+		}
+		return err
+	}
+	now := time.Now()
+	for _, result := range results {
+		baselines.Evaluate(result, now)
+	}
+	return baselines.Save()
+}
+
 // RunSyntheticTests runs synthetic tests on the provided applications and posts results to Slack.
+// Checks run concurrently, bounded by MAX_CONCURRENCY overall and MAX_CONCURRENCY_PER_HOST per host,
+// but results are reported in the order the applications appear in the config.
 func RunSyntheticTests(appData []*a.Application, targetAppName string) error {
-	found := false // Keep track of whether the app was found in the config file
-
 	var failingSyntheticTests []FailingSyntheticTest
 
 	v, _ := strconv.ParseBool(os.Getenv(envOutputSlack))
 	IsOutputSlack := v
 
-	for _, app := range appData {
-		if targetAppName == "" || targetAppName == app.Name {
-			found = true // The app was found in the config file
-			appStatus := app.GetStatus()
-			log.Println(appStatus)
-			if !appStatus.StatusOk || !appStatus.StatusContentOk || !appStatus.StatusCSPOk {
-				failingSyntheticTests = append(failingSyntheticTests, FailingSyntheticTest{AppStatus: *appStatus})
-				if !IsOutputSlack {
-					m.IncrementFailedTestsCounter(app.Name)
-				}
-			}
+	selectedApps := selectApplications(appData, targetAppName)
+	found := len(selectedApps) > 0 // Keep track of whether the app was found in the config file
 
-			if targetAppName != "" {
-				break
+	results := runChecks(selectedApps, getStatus, c.GetMaxConcurrency(), c.GetMaxConcurrencyPerHost())
+	if err := evaluateDrift(selectedApps, results, c.GetDriftStatePath()); err != nil {
+		// Losing the drift state must not lose the results of every check with it.
+		log.Println(err)
+	}
+	for i, appStatus := range results {
+		log.Println(appStatus)
+		if !appStatus.IsOk() {
+			failingSyntheticTests = append(failingSyntheticTests, FailingSyntheticTest{AppStatus: *appStatus})
+			if !IsOutputSlack {
+				m.IncrementFailedTestsCounter(selectedApps[i].Name)
 			}
 		}
 	}
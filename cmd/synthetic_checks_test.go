@@ -4,12 +4,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	a "github.com/NYULibraries/aswa/pkg/application"
 	c "github.com/NYULibraries/aswa/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type MockApplication struct {
@@ -222,3 +224,60 @@ func TestDoCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateDrift(t *testing.T) {
+	body := "console.log('bess')"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	statePath := filepath.Join(t.TempDir(), "drift.json")
+	apps := []*a.Application{
+		{Name: "bess-vue", URL: srv.URL, ExpectedStatusCode: http.StatusOK, Timeout: time.Second, Drift: &a.DriftCheck{}},
+		{Name: "no-drift", URL: srv.URL, ExpectedStatusCode: http.StatusOK, Timeout: time.Second},
+	}
+	run := func() []*a.AppCheckStatus {
+		results := runChecks(apps, getStatus, 2, 2)
+		require.NoError(t, evaluateDrift(apps, results, statePath))
+		return results
+	}
+
+	results := run()
+	require.NotNil(t, results[0].Drift)
+	assert.True(t, results[0].Drift.Recorded, "the first run records the baseline")
+	assert.Nil(t, results[1].Drift)
+	assert.FileExists(t, statePath)
+
+	results = run()
+	assert.True(t, results[0].IsOk(), "unchanged content is not drift")
+
+	body = ""
+	results = run()
+	assert.False(t, results[0].IsOk(), "an emptied file is drift")
+	assert.Contains(t, results[0].String(), "Failure: Drift URL "+srv.URL+" changed since")
+}
+
+func TestEvaluateDrift_NoDriftChecks(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "drift.json")
+	apps := []*a.Application{{Name: "test", URL: "test"}}
+
+	require.NoError(t, evaluateDrift(apps, []*a.AppCheckStatus{{Application: apps[0]}}, statePath))
+	assert.NoFileExists(t, statePath, "the state file is only written for drift checks")
+}
+
+func TestEvaluateDrift_UnreadableState(t *testing.T) {
+	statePath := t.TempDir() // a directory cannot be read as the state file
+	apps := []*a.Application{
+		{Name: "bess-vue", URL: "https://cdn.library.nyu.edu/bess-vue/app.min.js", Drift: &a.DriftCheck{}},
+		{Name: "no-drift", URL: "https://library.nyu.edu"},
+	}
+	results := []*a.AppCheckStatus{{Application: apps[0], StatusOk: true}, {Application: apps[1], StatusOk: true}}
+
+	err := evaluateDrift(apps, results, statePath)
+
+	require.Error(t, err)
+	require.NotNil(t, results[0].Drift)
+	assert.Contains(t, results[0].Drift.Err, "reading drift state", "the drift check reports the state file it could not read")
+	assert.Nil(t, results[1].Drift)
+}
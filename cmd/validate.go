@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	a "github.com/NYULibraries/aswa/pkg/application"
+	c "github.com/NYULibraries/aswa/pkg/config"
+)
+
+// ValidateCommand is the first argument that runs DoValidate instead of the checks.
+const ValidateCommand = c.CommandValidate
+
+// DoValidate loads each config path given, or YAML_PATH when there is none, reports every problem
+// and lints for suspicious settings, without sending any requests. With -schema it prints the
+// JSON Schema of the config format instead.
+func DoValidate(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet(ValidateCommand, flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() {
+		fmt.Fprintln(out, "Usage: aswa validate [-strict] [path ...]\n       aswa validate -schema")
+		flags.PrintDefaults()
+	}
+	printSchema := flags.Bool("schema", false, "print the JSON Schema of the config format")
+	strict := flags.Bool("strict", false, "fail on warnings as well as errors")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *printSchema {
+		schema, err := c.Schema()
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(schema)
+		return err
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{c.GetYamlPath()}
+	}
+	pageSizes, err := driftPageSizes(c.GetDriftStatePath())
+	if err != nil {
+		return err
+	}
+
+	var invalid []string
+	warnings := 0
+	for _, path := range paths {
+		config, err := c.NewConfig(path)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			invalid = append(invalid, path)
+			continue
+		}
+		problems := config.Lint(pageSizes)
+		for _, problem := range problems {
+			fmt.Fprintf(out, "warning: %s\n", problem)
+		}
+		warnings += len(problems)
+		if unsized := config.UnsizedPages(pageSizes); len(unsized) > 0 {
+			fmt.Fprintf(out, "skipped: page size of %s not checked, no drift baseline records it\n", strings.Join(unsized, ", "))
+		}
+		fmt.Fprintf(out, "%s: %d applications, %d warnings\n", path, len(config.Applications), len(problems))
+	}
+
+	switch {
+	case len(invalid) > 0:
+		return fmt.Errorf("invalid config: %s", strings.Join(invalid, ", "))
+	case *strict && warnings > 0:
+		return errors.New("config has warnings")
+	}
+	return nil
+}
+
+// driftPageSizes returns the page sizes recorded with the drift baselines, by application name.
+func driftPageSizes(statePath string) (map[string]int, error) {
+	baselines, err := a.LoadDriftBaselines(statePath)
+	if err != nil {
+		return nil, err
+	}
+	sizes := map[string]int{}
+	for name, baseline := range baselines.Baselines {
+		sizes[name] = baseline.Size
+	}
+	return sizes, nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	c "github.com/NYULibraries/aswa/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoValidate(t *testing.T) {
+	t.Setenv(c.EnvSkipWhitelistCheck, "true")
+	dir := t.TempDir()
+	t.Setenv(c.EnvDriftStatePath, filepath.Join(dir, "drift_baselines.json"))
+	valid := filepath.Join(dir, "valid.yml")
+	require.NoError(t, os.WriteFile(valid, []byte(`
+applications:
+  - name: library
+    url: 'https://library.nyu.edu'
+    expected_status: 200
+    timeout: 5s
+  - name: library-home
+    url: 'https://library.nyu.edu'
+    expected_status: 200
+    timeout: 5s
+  - name: primo-ve
+    url: 'https://search.library.nyu.edu/discovery/search?vid=01NYU_INST:NYU'
+    expected_status: 200
+    expected_content: 'NYU Libraries'
+    include_actual_content_on_failure: true
+    timeout: 5s
+`), 0o644))
+	invalid := filepath.Join(dir, "invalid.yml")
+	require.NoError(t, os.WriteFile(invalid, []byte(`
+applications:
+  - name: library
+    url: 'https://library.nyu.edu'
+    expected_stauts: 200
+`), 0o644))
+
+	var tests = []struct {
+		description string
+		args        []string
+		wantOutput  string
+		wantErr     string
+	}{
+		{"Warnings only", []string{valid},
+			"warning: " + valid + `:8:10: application "library-home": url https://library.nyu.edu is also checked by application "library"` + "\n" +
+				"skipped: page size of primo-ve not checked, no drift baseline records it\n" +
+				valid + ": 3 applications, 1 warnings\n", ""},
+		{"Warnings fail with -strict", []string{"-strict", valid}, "", "config has warnings"},
+		{"Invalid config", []string{valid, invalid},
+			"config file is invalid:\n" +
+				invalid + `:5:5: application "library": unknown field "expected_stauts", did you mean expected_status?` + "\n" +
+				invalid + `:3:5: application "library": missing required field expected_status` + "\n", "invalid config: " + invalid},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var out bytes.Buffer
+			err := DoValidate(test.args, &out)
+
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.wantErr)
+			}
+			assert.Contains(t, out.String(), test.wantOutput)
+		})
+	}
+}
+
+func TestDoValidate_Schema(t *testing.T) {
+	var out bytes.Buffer
+
+	require.NoError(t, DoValidate([]string{"-schema"}, &out))
+
+	schema, err := c.Schema()
+	require.NoError(t, err)
+	assert.Equal(t, string(schema), out.String())
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	a "github.com/NYULibraries/aswa/pkg/application"
+)
+
+// checkFunc performs a single synthetic check for an application.
+type checkFunc func(app *a.Application) *a.AppCheckStatus
+
+// hostLimiter hands out per-host semaphores so that no single host receives
+// more than a fixed number of concurrent checks.
+type hostLimiter struct {
+	mu    sync.Mutex
+	limit int
+	hosts map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{limit: limit, hosts: make(map[string]chan struct{})}
+}
+
+func (l *hostLimiter) acquire(host string) {
+	l.mu.Lock()
+	sem, ok := l.hosts[host]
+	if !ok {
+		sem = make(chan struct{}, l.limit)
+		l.hosts[host] = sem
+	}
+	l.mu.Unlock()
+	sem <- struct{}{}
+}
+
+func (l *hostLimiter) release(host string) {
+	l.mu.Lock()
+	sem := l.hosts[host]
+	l.mu.Unlock()
+	<-sem
+}
+
+// hostKey returns the key used to group an application's checks by host.
+// Applications whose target is not a URL, such as DNS names, are grouped by the raw target.
+func hostKey(app *a.Application) string {
+	parsed, err := url.Parse(app.Target())
+	if err != nil || parsed.Host == "" {
+		return app.Target()
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+// runChecks runs check for every application with at most maxWorkers checks in flight overall
+// and at most maxPerHost against the same host. A check takes its host's slot before a worker
+// slot, so checks queued for a busy host never hold up checks of other hosts.
+// Results are returned in the same order as apps, regardless of completion order.
+func runChecks(apps []*a.Application, check checkFunc, maxWorkers, maxPerHost int) []*a.AppCheckStatus {
+	results := make([]*a.AppCheckStatus, len(apps))
+	limiter := newHostLimiter(max(1, maxPerHost))
+	workers := make(chan struct{}, max(1, maxWorkers))
+
+	var wg sync.WaitGroup
+	for i, app := range apps {
+		wg.Go(func() {
+			host := hostKey(app)
+			limiter.acquire(host)
+			defer limiter.release(host)
+			workers <- struct{}{}
+			defer func() { <-workers }()
+
+			results[i] = check(app)
+		})
+	}
+	wg.Wait()
+
+	return results
+}
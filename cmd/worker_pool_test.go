@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	a "github.com/NYULibraries/aswa/pkg/application"
+	"github.com/stretchr/testify/assert"
+)
+
+// concurrencyTracker records the highest number of checks observed in flight,
+// both overall and per host.
+type concurrencyTracker struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	perHost     map[string]int
+	maxPerHost  map[string]int
+}
+
+func newConcurrencyTracker() *concurrencyTracker {
+	return &concurrencyTracker{perHost: map[string]int{}, maxPerHost: map[string]int{}}
+}
+
+func (ct *concurrencyTracker) enter(host string) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.inFlight++
+	ct.maxInFlight = max(ct.maxInFlight, ct.inFlight)
+	ct.perHost[host]++
+	ct.maxPerHost[host] = max(ct.maxPerHost[host], ct.perHost[host])
+}
+
+func (ct *concurrencyTracker) leave(host string) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.inFlight--
+	ct.perHost[host]--
+}
+
+func TestRunChecks_PreservesConfigOrder(t *testing.T) {
+	var apps []*a.Application
+	for i := range 6 {
+		apps = append(apps, &a.Application{Name: fmt.Sprintf("app%d", i), URL: fmt.Sprintf("https://host%d.example.com", i)})
+	}
+
+	// Earlier applications take longer, so they finish last.
+	check := func(app *a.Application) *a.AppCheckStatus {
+		var i int
+		_, _ = fmt.Sscanf(app.Name, "app%d", &i)
+		time.Sleep(time.Duration(len(apps)-i) * 10 * time.Millisecond)
+		return &a.AppCheckStatus{Application: app}
+	}
+
+	results := runChecks(apps, check, 6, 1)
+
+	assert.Len(t, results, len(apps))
+	for i, result := range results {
+		assert.Same(t, apps[i], result.Application, "result %d should belong to application %s", i, apps[i].Name)
+	}
+}
+
+func TestRunChecks_RespectsLimits(t *testing.T) {
+	var tests = []struct {
+		description string
+		hosts       []string
+		maxWorkers  int
+		maxPerHost  int
+	}{
+		{"Global limit across distinct hosts", []string{"a", "b", "c", "d", "e", "f", "g", "h"}, 3, 2},
+		{"Per-host limit on a single host", []string{"a", "a", "a", "a", "a", "a"}, 4, 2},
+		{"Mixed hosts", []string{"a", "a", "a", "b", "b", "b", "c", "c"}, 5, 1},
+		{"Serial run", []string{"a", "b", "c"}, 1, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var apps []*a.Application
+			for i, host := range test.hosts {
+				apps = append(apps, &a.Application{Name: fmt.Sprintf("app%d", i), URL: "https://" + host + ".example.com/path"})
+			}
+
+			tracker := newConcurrencyTracker()
+			var calls atomic.Int32
+			check := func(app *a.Application) *a.AppCheckStatus {
+				host := hostKey(app)
+				tracker.enter(host)
+				time.Sleep(20 * time.Millisecond)
+				tracker.leave(host)
+				calls.Add(1)
+				return &a.AppCheckStatus{Application: app}
+			}
+
+			runChecks(apps, check, test.maxWorkers, test.maxPerHost)
+
+			assert.Equal(t, int32(len(apps)), calls.Load(), "every application should be checked exactly once")
+			assert.LessOrEqual(t, tracker.maxInFlight, test.maxWorkers, "global concurrency limit exceeded")
+			for host, peak := range tracker.maxPerHost {
+				assert.LessOrEqual(t, peak, test.maxPerHost, "per-host concurrency limit exceeded for %s", host)
+			}
+		})
+	}
+}
+
+// A check of an idle host must not wait behind checks queued for a busy one.
+func TestRunChecks_BusyHostDoesNotBlockOthers(t *testing.T) {
+	var apps []*a.Application
+	for i := range 4 {
+		apps = append(apps, &a.Application{Name: fmt.Sprintf("busy%d", i), URL: "https://busy.example.com"})
+	}
+	apps = append(apps, &a.Application{Name: "idle", URL: "https://idle.example.com"})
+
+	start := time.Now()
+	var idleDone time.Duration
+	check := func(app *a.Application) *a.AppCheckStatus {
+		time.Sleep(40 * time.Millisecond)
+		if app.Name == "idle" {
+			idleDone = time.Since(start)
+		}
+		return &a.AppCheckStatus{Application: app}
+	}
+
+	runChecks(apps, check, 2, 1)
+
+	assert.Less(t, idleDone, 80*time.Millisecond, "the idle host's check should run alongside the first busy one")
+}
+
+func TestRunChecks_NoApplications(t *testing.T) {
+	results := runChecks(nil, func(app *a.Application) *a.AppCheckStatus {
+		t.Fatal("check should not be called")
+		return nil
+	}, 4, 2)
+	assert.Empty(t, results)
+}
+
+func TestHostKey(t *testing.T) {
+	var tests = []struct {
+		description string
+		url         string
+		want        string
+	}{
+		{"Host is lowercased", "https://CDN.Library.nyu.edu/bess-vue/app.min.js", "cdn.library.nyu.edu"},
+		{"Port is kept", "https://sfx.library.nyu.edu:443/sfxlcl41", "sfx.library.nyu.edu:443"},
+		{"Unparseable URL falls back to raw value", "test", "test"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.want, hostKey(&a.Application{URL: test.url}))
+		})
+	}
+
+	dnsCheck := &a.Application{Type: a.CheckDNS, DNS: &a.DNSCheck{Name: "libguides.nyu.edu"}}
+	assert.Equal(t, "libguides.nyu.edu", hostKey(dnsCheck), "DNS checks are grouped by the name they resolve")
+}
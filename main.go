@@ -2,12 +2,21 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/NYULibraries/aswa/cmd"
 )
 
 func main() {
-	err := cmd.DoCheck()
+	var err error
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == cmd.ValidateCommand:
+		err = cmd.DoValidate(os.Args[2:], os.Stdout)
+	case len(os.Args) > 1 && os.Args[1] == cmd.AcceptDriftCommand:
+		err = cmd.DoAcceptDrift(os.Args[2:], os.Stdout)
+	default:
+		err = cmd.DoCheck()
+	}
 	if err != nil {
 		log.Fatal("Error:", err)
 	}
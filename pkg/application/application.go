@@ -36,15 +36,45 @@ func parseEnvBool(envVar string) bool {
 
 // Application represents a synthetic test on an external url to perform
 type Application struct {
-	Name                          string        `yaml:"name"`
-	URL                           string        `yaml:"url"`
-	ExpectedStatusCode            int           `yaml:"expected_status"`
-	Timeout                       time.Duration `yaml:"timeout"`
-	IncludeActualContentOnFailure bool          `yaml:"include_actual_content_on_failure"`
-	MaxRedirects                  int           `yaml:"max_redirects"`
-	ExpectedLocation              string        `yaml:"expected_location"`
-	ExpectedContent               string        `yaml:"expected_content"`
-	ExpectedCSP                   string        `yaml:"expected_csp"`
+	Name                          string                     `yaml:"name"`
+	Group                         string                     `yaml:"group"`
+	Type                          string                     `yaml:"type"`
+	URL                           string                     `yaml:"url"`
+	ExpectedStatusCode            int                        `yaml:"expected_status"`
+	Timeout                       time.Duration              `yaml:"timeout"`
+	IncludeActualContentOnFailure bool                       `yaml:"include_actual_content_on_failure"`
+	MaxRedirects                  int                        `yaml:"max_redirects"`
+	ExpectedLocation              string                     `yaml:"expected_location"`
+	ExpectedContent               ContentAssertions          `yaml:"expected_content"`
+	ExpectedCSP                   string                     `yaml:"expected_csp"`
+	CSPMode                       string                     `yaml:"csp_mode"`
+	Retries                       int                        `yaml:"retries"`
+	RetryBackoff                  time.Duration              `yaml:"retry_backoff"`
+	Method                        string                     `yaml:"method"`
+	Headers                       map[string]string          `yaml:"headers"`
+	Body                          string                     `yaml:"body"`
+	BodyFile                      string                     `yaml:"body_file"`
+	Auth                          *Auth                      `yaml:"auth"`
+	ExpectedJSON                  []JSONAssertion            `yaml:"expected_json"`
+	ExpectedHTML                  []HTMLAssertion            `yaml:"expected_html"`
+	ExpectedXML                   []XMLAssertion             `yaml:"expected_xml"`
+	XMLNamespaces                 map[string]string          `yaml:"xml_namespaces"`
+	MaxResponseTime               time.Duration              `yaml:"max_response_time"`
+	WarnResponseTime              time.Duration              `yaml:"warn_response_time"`
+	MinCertDaysRemaining          int                        `yaml:"min_cert_days_remaining"`
+	ExpectedCertIssuer            string                     `yaml:"expected_cert_issuer"`
+	ExpectedCertHostnames         []string                   `yaml:"expected_cert_hostnames"`
+	TLS                           *TLSSettings               `yaml:"tls"`
+	ExpectedRedirects             []RedirectExpectation      `yaml:"expected_redirects"`
+	ExpectedFinalURL              string                     `yaml:"expected_final_url"`
+	ExpectedHeaders               map[string]HeaderAssertion `yaml:"expected_headers"`
+	SecurityProfile               string                     `yaml:"security_profile"`
+	Drift                         *DriftCheck                `yaml:"drift"`
+	CheckAssets                   bool                       `yaml:"check_assets"`
+	AssetHosts                    []string                   `yaml:"asset_hosts"`
+	DNS                           *DNSCheck                  `yaml:"dns"`
+	TCP                           *TCPCheck                  `yaml:"tcp"`
+	Steps                         []Step                     `yaml:"steps"`
 }
 
 // AppCheckStatus represents the results of a synthetic test
@@ -57,11 +87,37 @@ type AppCheckStatus struct {
 	ActualLocation   string `default:""`
 	ActualContent    string `default:""`
 	ActualCSP        string `default:""`
+	Error            string `default:""`
+	CSPDifferences   []CSPDifference
+	Attempts         []Attempt
+	ContentResults   []ContentResult
+	JSONResults      []JSONResult
+	HTMLResults      []HTMLResult
+	XMLResults       []XMLResult
+	Timings          Timings
+	// ResponseTimeExceeded fails the check; ResponseTimeWarning only reports it as slow.
+	ResponseTimeExceeded bool
+	ResponseTimeWarning  bool
+	Certificates         []CertificateInfo
+	CertResults          []CertResult
+	TLSResults           []TLSResult
+	RedirectChain        []RedirectHop
+	RedirectResults      []RedirectResult
+	HeaderResults        []HeaderResult
+	SecurityResults      []SecurityResult
+	AssetResults         []AssetResult
+	Fingerprint          *Fingerprint
+	Drift                *DriftResult
+	DNSResults           []DNSResult
+	Steps                []StepResult
 }
 
-// SetIsPrimoVE sets the IsPrimoVE flag based on the yamlPath.
+// SetIsPrimoVE sets the IsPrimoVE flag based on the yamlPath: the Primo VE config file, the
+// config/primo_ve.d directory, or a file or glob inside it.
 func SetIsPrimoVE(yamlPath string) {
-	IsPrimoVE = filepath.Base(filepath.Clean(yamlPath)) == "primo_ve.applications.yml"
+	clean := filepath.Clean(yamlPath)
+	IsPrimoVE = filepath.Base(clean) == "primo_ve.applications.yml" || filepath.Base(clean) == "primo_ve.d" ||
+		filepath.Base(filepath.Dir(clean)) == "primo_ve.d"
 }
 
 // compareStatusCodes compares the actual and expected status codes.
@@ -117,15 +173,32 @@ func compareContent(actual, expected string) (bool, string) {
 	return true, actual[index : index+len(expected)]
 }
 
-// compareCSP compares the actual and expected CSP.
-func compareCSP(actual string, expected string) bool {
-	if expected == "" {
-		return true // No CSP check required
+// IsOk reports whether every configured expectation of the synthetic test passed.
+func (results AppCheckStatus) IsOk() bool {
+	return results.StatusOk && results.StatusContentOk && results.StatusCSPOk && !results.ResponseTimeExceeded &&
+		allOk(results.CertResults) && allOk(results.TLSResults) &&
+		allOk(results.RedirectResults) && allOk(results.HeaderResults) &&
+		allOk(results.SecurityResults) && allOk(results.DNSResults) &&
+		allOk(results.AssetResults) &&
+		allOk(results.Steps) && driftResultOk(results.Drift)
+}
+
+// assertionResult is the outcome of one assertion, e.g. a CertResult or a HeaderResult.
+type assertionResult interface {
+	passed() bool
+}
+
+// allOk reports whether every result passed.
+func allOk[T assertionResult](results []T) bool {
+	for _, result := range results {
+		if !result.passed() {
+			return false
+		}
 	}
-	return actual == expected
+	return true
 }
 
-// GetStatus performs an HTTP request for the given application's URL and evaluates
+// getStatusOnce performs an HTTP request for the given application's URL and evaluates
 // its response against expected criteria such as status code, redirect location,
 // and optional content or CSP header.
 //
@@ -133,19 +206,39 @@ func compareCSP(actual string, expected string) bool {
 //   - Always validates the original URL’s HTTP status and redirect (no auto-follow).
 //   - If ExpectedContent is configured, also performs a GET request to fetch and
 //     validate page content (optionally following the expected redirect).
-func (test Application) GetStatus() *AppCheckStatus {
-	client := createClient(test.Timeout)
+func (test Application) getStatusOnce() *AppCheckStatus {
+	switch {
+	case test.isDNS():
+		return test.getDNSStatus()
+	case test.isTCP():
+		return test.getTCPStatus()
+	case test.hasSteps():
+		return test.getStepsStatus()
+	}
+
+	client, err := createClient(test)
+	if err != nil {
+		return createApplicationStatus(test, nil, err, "", false)
+	}
+	defer closeIdleConnections(client)
 
 	var resp *http.Response
-	var err error
+	var page *pageResult
 	var actualContent string
 	var statusContentOk bool
+	var timings Timings
 
 	// Phase 1: probe ORIGINAL URL (status + Location), preferring HEAD but
 	// falling back to a no-redirect GET when the server does not support HEAD.
-	resp, err = performProbeRequest(test, client)
+	// The probe is traced phase by phase; phase 2 below is only timed as a whole.
+	probeClient := *client
+	probeClient.Transport = newTimingTransport(client.Transport, &timings)
+	resp, err = performProbeRequest(test, &probeClient)
 	if err != nil {
-		return createApplicationStatus(test, resp, err, "", false)
+		status := createApplicationStatus(test, resp, err, "", false)
+		status.CertResults = certErrorResults(test, err)
+		status.Timings = timings
+		return status
 	}
 	if resp == nil {
 		return createApplicationStatus(test, resp, fmt.Errorf("nil probe response"), "", false)
@@ -153,27 +246,32 @@ func (test Application) GetStatus() *AppCheckStatus {
 	defer closeResponseBody(resp.Body)
 
 	if DebugMode {
-		log.Printf("[probe] url=%s status=%d location=%q",
-			test.URL, resp.StatusCode, resp.Header.Get("Location"))
+		log.Printf("[probe] url=%s status=%d location=%q timings=%q",
+			test.URL, resp.StatusCode, resp.Header.Get("Location"), timings)
 	}
 
-	// Phase 2: content on the FINAL landing page (follow all redirects)
-	if test.IsGet() {
-		maxRedirects := test.MaxRedirects
-		if maxRedirects <= 0 {
-			maxRedirects = defaultMaxRedirects
-		}
+	// Phase 2: content and redirect chain of the FINAL landing page (follow all redirects)
+	var chain []RedirectHop
+	if test.IsGet() || test.followsRedirects() || test.SecurityProfile != "" || len(test.ExpectedHeaders) > 0 {
+		maxRedirects := test.redirectLimit()
 
 		// Clone client and set redirect handler for visibility and cap
 		followClient := *client
+		if resp.Request != nil && resp.Request.Method == test.requestMethod() {
+			// The probe already sent this request, e.g. a POST or the GET fallback; answer it
+			// with the probe's response rather than sending it again, and only follow redirects.
+			followClient.Transport = &replayTransport{base: client.Transport, resp: resp}
+		}
 		followClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			if DebugMode {
 				prev := via[len(via)-1].URL
-				log.Printf("[GET redirect] hop=%d %s -> %s", len(via), prev, req.URL)
+				log.Printf("[GET redirect] hop=%d %s -> %s", len(via), test.Auth.redactURL(prev), test.Auth.redactURL(req.URL))
 			}
 			if len(via) >= maxRedirects {
 				return fmt.Errorf("stopped after %d redirects", maxRedirects)
 			}
+			recordRedirect(test, &chain, req, via)
+			test.Auth.stripOnCrossHostRedirect(req, via)
 			return nil
 		}
 
@@ -181,39 +279,75 @@ func (test Application) GetStatus() *AppCheckStatus {
 			log.Printf("[GET start] url=%s", test.URL)
 		}
 
-		var respStatusCode int
-		var finalURL string
-		respStatusCode, finalURL, actualContent, statusContentOk, err =
-			performGetRequest(test, &followClient)
+		pageStart := time.Now()
+		page, err = performGetRequest(test, &followClient)
+		timings.Page = time.Since(pageStart)
 		if err != nil {
 			if DebugMode {
 				log.Printf("[GET error] url=%s error=%v", test.URL, err)
 			}
-			return createApplicationStatus(test, nil, err, "", false)
+			status := createApplicationStatus(test, nil, err, "", false)
+			status.RedirectChain = chain
+			status.Timings = timings
+			return status
 		}
+		chain = append(chain, RedirectHop{URL: page.finalURL, Status: page.statusCode})
 
 		if DebugMode {
 			log.Printf("[GET final] status=%d url=%s bodyLen=%d",
-				respStatusCode, finalURL, len(actualContent))
+				page.statusCode, page.finalURL, len(page.body))
 		}
+		actualContent, statusContentOk = page.actualContent, page.statusContentOk
 	} else {
 		statusContentOk = true
 	}
 
-	return createApplicationStatus(test, resp, nil, actualContent, statusContentOk)
+	status := createApplicationStatus(test, resp, nil, actualContent, statusContentOk)
+	if page != nil {
+		status.ContentResults = page.contentResults
+		status.JSONResults = page.jsonResults
+		status.HTMLResults = page.htmlResults
+		status.XMLResults = page.xmlResults
+	}
+	status.Timings = timings
+	status.ResponseTimeExceeded, status.ResponseTimeWarning = test.evaluateResponseTime(timings.responseTime())
+	status.Certificates = certificateChain(resp.TLS)
+	status.CertResults = test.evaluateCertificates(resp.TLS, time.Now())
+	status.TLSResults = test.evaluateTLS(resp.TLS)
+	status.RedirectChain = chain
+	status.RedirectResults = test.evaluateRedirects(chain)
+	if page != nil {
+		// Headers are always asserted on the final response, which is fetched whenever they are
+		// set; a HEAD probe may answer with different headers, and a redirect with none of the page's.
+		status.HeaderResults = evaluateHeaders(page.header, test.ExpectedHeaders)
+		status.SecurityResults = test.auditSecurity(client, page.finalURL, page.header)
+		status.AssetResults = test.checkAssets(client, page)
+		if test.Drift != nil {
+			fingerprint := test.Drift.fingerprint(page.body)
+			status.Fingerprint = &fingerprint
+		}
+	}
+	return status
 }
 
-func createClient(timeout time.Duration) *http.Client {
+func createClient(test Application) (*http.Client, error) {
+	transport, err := test.newTransport()
+	if err != nil {
+		return nil, err
+	}
 	return &http.Client{
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
-		Timeout: timeout,
-	}
+		Timeout:   test.Timeout,
+		Transport: transport,
+	}, nil
 }
 
+// IsGet reports whether the check needs the body of the final landing page.
 func (test Application) IsGet() bool {
-	return test.ExpectedContent != ""
+	return len(test.ExpectedContent) > 0 || len(test.ExpectedJSON) > 0 ||
+		len(test.ExpectedHTML) > 0 || len(test.ExpectedXML) > 0 || test.Drift != nil || test.CheckAssets
 }
 
 func closeResponseBody(body io.ReadCloser) {
@@ -222,45 +356,70 @@ func closeResponseBody(body io.ReadCloser) {
 	}
 }
 
-func performGetRequest(test Application, client *http.Client) (int, string, string, bool, error) {
-	req, err := http.NewRequest(http.MethodGet, test.URL, nil)
+// pageResult holds the final landing page fetched in phase 2 and the outcome of its body assertions.
+type pageResult struct {
+	statusCode      int
+	finalURL        string
+	header          http.Header
+	body            string
+	actualContent   string
+	statusContentOk bool
+	contentResults  []ContentResult
+	jsonResults     []JSONResult
+	htmlResults     []HTMLResult
+	xmlResults      []XMLResult
+}
+
+func performGetRequest(test Application, client *http.Client) (*pageResult, error) {
+	req, err := newRequest(test, test.requestMethod())
 	if err != nil {
-		return 0, "", "", false, err
+		return nil, err
 	}
 
-	req.Header.Set("User-Agent", userAgent)
-
-	resp, err := client.Do(req)
+	resp, err := doRequest(test, client, req)
 	if err != nil {
-		return 0, "", "", false, err
+		return nil, err
 	}
 	defer closeResponseBody(resp.Body)
 
 	buf := new(bytes.Buffer)
 	if _, err := io.Copy(buf, io.LimitReader(resp.Body, maxResponseBodyBytes)); err != nil {
 		log.Println("Error copying response body:", err)
-		return resp.StatusCode, resp.Request.URL.String(), "", false, err
+		return nil, err
 	}
 
-	actualContent := buf.String()
-	statusContentOk, matchedContent := compareContent(actualContent, test.ExpectedContent)
-	if statusContentOk {
-		actualContent = matchedContent
+	page := &pageResult{
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       buf.String(),
 	}
-
-	finalURL := ""
 	if resp.Request != nil && resp.Request.URL != nil {
-		finalURL = resp.Request.URL.String()
+		page.finalURL = test.Auth.redactURL(resp.Request.URL)
 	}
 
-	return resp.StatusCode, finalURL, actualContent, statusContentOk, nil
+	page.statusContentOk, page.actualContent, page.contentResults = evaluateContent(page.body, test.ExpectedContent)
+	jsonOk, jsonResults := evaluateJSON(page.body, test.ExpectedJSON)
+	page.statusContentOk = page.statusContentOk && jsonOk
+	page.jsonResults = jsonResults
+	htmlOk, htmlResults := evaluateHTML(page.body, test.ExpectedHTML)
+	page.statusContentOk = page.statusContentOk && htmlOk
+	page.htmlResults = htmlResults
+	xmlOk, xmlResults := evaluateXML(page.body, test.ExpectedXML, test.XMLNamespaces)
+	page.statusContentOk = page.statusContentOk && xmlOk
+	page.xmlResults = xmlResults
+
+	return page, nil
 }
 
 // performProbeRequest inspects the original URL's status, Location, and headers
 // without following redirects. It prefers HEAD, but falls back to a no-redirect GET
 // when the server does not support HEAD (transport error or 405 Method Not Allowed)
 // so that status/CSP checks are not failed spuriously by HEAD-hostile endpoints.
+// Checks configured with another method (e.g. POST) are probed with that method.
 func performProbeRequest(test Application, client *http.Client) (*http.Response, error) {
+	if !test.probesWithHead() {
+		return performMethodRequest(test, client)
+	}
 	resp, err := performHeadRequest(test, client)
 	if !headUnsupported(test, resp, err) {
 		return resp, err
@@ -287,23 +446,54 @@ func headUnsupported(test Application, resp *http.Response, err error) bool {
 }
 
 func performHeadRequest(test Application, client *http.Client) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodHead, test.URL, nil)
+	req, err := newRequest(test, http.MethodHead)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("User-Agent", userAgent)
-	return client.Do(req)
+	return doRequest(test, client, req)
 }
 
 // performProbeGetRequest issues a GET without following redirects (the client's
 // CheckRedirect prevents following), used as a fallback when HEAD is unsupported.
 func performProbeGetRequest(test Application, client *http.Client) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, test.URL, nil)
+	req, err := newRequest(test, http.MethodGet)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("User-Agent", userAgent)
-	return client.Do(req)
+	return doRequest(test, client, req)
+}
+
+// performMethodRequest issues the check's configured method without following redirects,
+// used as the probe for methods that cannot be replaced by HEAD.
+func performMethodRequest(test Application, client *http.Client) (*http.Response, error) {
+	req, err := newRequest(test, test.requestMethod())
+	if err != nil {
+		return nil, err
+	}
+	return doRequest(test, client, req)
+}
+
+// replayTransport answers its first round trip with a response that was already received,
+// and sends later round trips, e.g. redirects, through base.
+type replayTransport struct {
+	base http.RoundTripper
+	resp *http.Response
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.resp == nil {
+		if t.base == nil {
+			return http.DefaultTransport.RoundTrip(req)
+		}
+		return t.base.RoundTrip(req)
+	}
+	if req.Body != nil {
+		closeResponseBody(req.Body)
+	}
+	replayed := *t.resp
+	replayed.Request = req
+	t.resp = nil
+	return &replayed, nil
 }
 
 func createApplicationStatus(test Application, resp *http.Response, err error, actualContent string, statusContentOk bool) *AppCheckStatus {
@@ -312,9 +502,13 @@ func createApplicationStatus(test Application, resp *http.Response, err error, a
 	actualStatusCode := 0
 	actualLocation := ""
 	actualCSP := ""
+	var cspDifferences []CSPDifference
+
+	errorMessage := ""
 
 	if err != nil {
 		log.Printf("[%s] Request error: %v", test.Name, err)
+		errorMessage = err.Error()
 		actualContent = ""
 		statusContentOk = false
 		statusCSPOk = false
@@ -332,7 +526,7 @@ func createApplicationStatus(test Application, resp *http.Response, err error, a
 		// Determine the statusCSPOk
 		if test.ExpectedCSP != "" {
 			actualCSP = resp.Header.Get("Content-Security-Policy")
-			statusCSPOk = compareCSP(actualCSP, test.ExpectedCSP)
+			statusCSPOk, cspDifferences = compareCSP(actualCSP, test.ExpectedCSP, test.CSPMode)
 		}
 	}
 
@@ -345,12 +539,47 @@ func createApplicationStatus(test Application, resp *http.Response, err error, a
 		ActualLocation:   actualLocation,
 		ActualContent:    actualContent,
 		ActualCSP:        actualCSP,
+		Error:            errorMessage,
+		CSPDifferences:   cspDifferences,
 	}
 }
 
 // String outputs the application status as a single string
 func (results AppCheckStatus) String() string {
 	var output []string
+	switch {
+	case results.Application.isDNS():
+		output = dnsResultsStrings(results)
+	case results.Application.isTCP():
+		output = tcpResultsStrings(results)
+	case results.Application.hasSteps():
+		output = stepsResultsStrings(results)
+	default:
+		output = httpResultsStrings(results)
+	}
+
+	for _, result := range results.CertResults {
+		output = append(output, certResultString(result))
+	}
+	for _, result := range results.TLSResults {
+		output = append(output, tlsResultString(result))
+	}
+
+	// DNS checks report their lookup time in their status line.
+	if !results.Application.isDNS() && results.Timings.Total > 0 {
+		output = append(output, responseTimeString(results))
+	}
+
+	if len(results.Attempts) > 1 {
+		output = append(output, attemptsString(results))
+	}
+
+	return strings.Join(output, "\n")
+}
+
+// httpResultsStrings reports the status, body, header, CSP and redirect results of an HTTP check.
+func httpResultsStrings(results AppCheckStatus) []string {
+	var output []string
 
 	if results.StatusOk {
 		output = append(output, successString(results))
@@ -358,14 +587,42 @@ func (results AppCheckStatus) String() string {
 		output = append(output, failureString(results))
 	}
 
-	if results.Application.ExpectedContent != "" {
-		if results.StatusContentOk {
+	if len(results.Application.ExpectedContent) > 0 {
+		if len(results.ContentResults) > 0 {
+			output = append(output, contentResultsStrings(results)...)
+		} else if results.StatusContentOk {
 			output = append(output, contentSuccessString(results))
 		} else {
 			output = append(output, contentFailureString(results))
 		}
 	}
 
+	for _, result := range results.JSONResults {
+		output = append(output, jsonResultString(result))
+	}
+	for _, result := range results.HTMLResults {
+		output = append(output, htmlResultString(result))
+	}
+	for _, result := range results.XMLResults {
+		output = append(output, xmlResultString(result))
+	}
+
+	for _, result := range results.HeaderResults {
+		output = append(output, headerResultString(result))
+	}
+
+	for _, result := range results.SecurityResults {
+		output = append(output, securityResultString(result))
+	}
+
+	if results.Application.CheckAssets && results.Error == "" {
+		output = append(output, assetResultsStrings(results.AssetResults)...)
+	}
+
+	if results.Drift != nil {
+		output = append(output, driftResultString(results.Application, *results.Drift))
+	}
+
 	// Handling the CSP check status
 	if results.Application.ExpectedCSP != "" {
 		if results.StatusCSPOk {
@@ -375,7 +632,15 @@ func (results AppCheckStatus) String() string {
 		}
 	}
 
-	return strings.Join(output, "\n")
+	for _, result := range results.RedirectResults {
+		output = append(output, redirectResultString(result))
+	}
+	if results.Application.followsRedirects() && len(results.RedirectChain) > 0 &&
+		(!allOk(results.RedirectResults) || results.Error != "") {
+		output = append(output, redirectChainString(results.RedirectChain))
+	}
+
+	return output
 }
 
 func successString(results AppCheckStatus) string {
@@ -447,7 +712,7 @@ func cspSuccessString(results AppCheckStatus) string {
 
 func cspFailureString(results AppCheckStatus) string {
 	if results.ActualCSP != "" {
-		return fmt.Sprintf("Failure: Expected Primo VE CSP header did not match Actual CSP header: %s", results.ActualCSP)
+		return fmt.Sprintf("Failure: Expected Primo VE CSP header did not match Actual CSP header: %s", cspDifferencesString(results.CSPDifferences))
 	}
 	return "Failure: No Primo VE CSP header to compare"
 }
@@ -64,7 +64,7 @@ func TestGetStatus(t *testing.T) {
 				ExpectedStatusCode: http.StatusFound,
 				Timeout:            2 * time.Second,
 				ExpectedLocation:   "/login",
-				ExpectedContent:    "Log into your account",
+				ExpectedContent:    ContentAssertions{{Value: "Log into your account"}},
 			},
 			expectedSuccess:          true,
 			expectedActualStatusCode: http.StatusFound,
@@ -82,7 +82,7 @@ func TestGetStatus(t *testing.T) {
 				ExpectedStatusCode: http.StatusFound,
 				Timeout:            2 * time.Second,
 				ExpectedLocation:   "/login",
-				ExpectedContent:    "Log into your account",
+				ExpectedContent:    ContentAssertions{{Value: "Log into your account"}},
 			},
 			expectedSuccess:          false,
 			expectedActualStatusCode: http.StatusFound,
@@ -170,8 +170,8 @@ func TestGetStatus(t *testing.T) {
 			expectedCSPSuccess:       true,
 			expectedActualCSP:        "",
 		},
-		{"Failure: wrong status code expected", &Application{Name: "", URL: mockServer.URL + "/wrongstatus", ExpectedStatusCode: http.StatusOK, Timeout: 800 * time.Millisecond, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: "", ExpectedCSP: ""}, false, http.StatusNotFound, "", true, "", true, ""},
-		{"Failure: application is down", &Application{Name: "", URL: mockServer.URL + "/500", ExpectedStatusCode: http.StatusOK, Timeout: 800 * time.Millisecond, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: "", ExpectedCSP: ""}, false, http.StatusInternalServerError, "", true, "", true, ""},
+		{"Failure: wrong status code expected", &Application{Name: "", URL: mockServer.URL + "/wrongstatus", ExpectedStatusCode: http.StatusOK, Timeout: 800 * time.Millisecond, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: nil, ExpectedCSP: ""}, false, http.StatusNotFound, "", true, "", true, ""},
+		{"Failure: application is down", &Application{Name: "", URL: mockServer.URL + "/500", ExpectedStatusCode: http.StatusOK, Timeout: 800 * time.Millisecond, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: nil, ExpectedCSP: ""}, false, http.StatusInternalServerError, "", true, "", true, ""},
 		{
 			description: "Success: slow response within timeout",
 			application: &Application{
@@ -187,7 +187,7 @@ func TestGetStatus(t *testing.T) {
 			expectedCSPSuccess:       true,
 			expectedActualCSP:        "",
 		},
-		{"Failure: timeout", &Application{Name: "", URL: mockServer.URL + "/slowresponse", ExpectedStatusCode: http.StatusOK, Timeout: 1 * time.Millisecond, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: "", ExpectedCSP: ""}, false, 0, "", false, "", false, ""},
+		{"Failure: timeout", &Application{Name: "", URL: mockServer.URL + "/slowresponse", ExpectedStatusCode: http.StatusOK, Timeout: 1 * time.Millisecond, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: nil, ExpectedCSP: ""}, false, 0, "", false, "", false, ""},
 		{
 			description: "Failure: wrong error expected",
 			application: &Application{
@@ -203,10 +203,10 @@ func TestGetStatus(t *testing.T) {
 			expectedCSPSuccess:       true,
 			expectedActualCSP:        "",
 		},
-		{"Success: expected content found", &Application{Name: "", URL: mockServer.URL + "/html", ExpectedStatusCode: http.StatusOK, Timeout: 5 * time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: "Herman Melville", ExpectedCSP: ""}, true, http.StatusOK, "", true, "Herman Melville", true, ""},
-		{"Failure: expected content not found", &Application{Name: "", URL: mockServer.URL + "/html", ExpectedStatusCode: http.StatusOK, Timeout: 5 * time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: "Jules Verne - 20,000 Leagues Under the Sea", ExpectedCSP: ""}, true, http.StatusOK, "", false, "", true, ""},
-		{"Success: expected CSP header found", &Application{Name: "", URL: mockServer.URL + "/html", ExpectedStatusCode: http.StatusOK, Timeout: 5 * time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: "", ExpectedCSP: "default-src 'self'"}, true, http.StatusOK, "", true, "", true, "default-src 'self'"},
-		{"Failure: expected CSP header not found", &Application{Name: "", URL: mockServer.URL + "/html", ExpectedStatusCode: http.StatusOK, Timeout: 5 * time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: "", ExpectedCSP: "default-src 'none'"}, true, http.StatusOK, "", true, "", false, ""},
+		{"Success: expected content found", &Application{Name: "", URL: mockServer.URL + "/html", ExpectedStatusCode: http.StatusOK, Timeout: 5 * time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: ContentAssertions{{Value: "Herman Melville"}}, ExpectedCSP: ""}, true, http.StatusOK, "", true, "Herman Melville", true, ""},
+		{"Failure: expected content not found", &Application{Name: "", URL: mockServer.URL + "/html", ExpectedStatusCode: http.StatusOK, Timeout: 5 * time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: ContentAssertions{{Value: "Jules Verne - 20,000 Leagues Under the Sea"}}, ExpectedCSP: ""}, true, http.StatusOK, "", false, "", true, ""},
+		{"Success: expected CSP header found", &Application{Name: "", URL: mockServer.URL + "/html", ExpectedStatusCode: http.StatusOK, Timeout: 5 * time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: nil, ExpectedCSP: "default-src 'self'"}, true, http.StatusOK, "", true, "", true, "default-src 'self'"},
+		{"Failure: expected CSP header not found", &Application{Name: "", URL: mockServer.URL + "/html", ExpectedStatusCode: http.StatusOK, Timeout: 5 * time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: nil, ExpectedCSP: "default-src 'none'"}, true, http.StatusOK, "", true, "", false, ""},
 	}
 
 	for _, test := range tests {
@@ -262,7 +262,7 @@ func TestCreateApplicationStatus(t *testing.T) {
 		ExpectedStatusCode: http.StatusOK,
 		Timeout:            500 * time.Millisecond,
 		ExpectedLocation:   "",
-		ExpectedContent:    "Successful Request",
+		ExpectedContent:    ContentAssertions{{Value: "Successful Request"}},
 	}
 
 	appWithoutContent := Application{
@@ -270,7 +270,7 @@ func TestCreateApplicationStatus(t *testing.T) {
 		ExpectedStatusCode: http.StatusOK,
 		Timeout:            500 * time.Millisecond,
 		ExpectedLocation:   "",
-		ExpectedContent:    "",
+		ExpectedContent:    nil,
 	}
 
 	var tests = []struct {
@@ -292,6 +292,7 @@ func TestCreateApplicationStatus(t *testing.T) {
 				ActualStatusCode: 0,
 				ActualLocation:   "",
 				ActualContent:    "",
+				Error:            fmt.Sprintf("Get %q: simulated network error", app.URL),
 			},
 		},
 		{
@@ -306,6 +307,7 @@ func TestCreateApplicationStatus(t *testing.T) {
 				ActualStatusCode: 0,
 				ActualLocation:   "",
 				ActualContent:    "",
+				Error:            fmt.Sprintf("Get %q: simulated network error", app.URL),
 			},
 		},
 		{
@@ -361,12 +363,13 @@ func TestCreateApplicationStatus(t *testing.T) {
 			var err error
 			var actualContent string
 			var statusContentOk bool
-			var statusCode int
 
 			if test.app.IsGet() {
-				statusCode, _, actualContent, statusContentOk, err = performGetRequest(test.app, client)
+				var page *pageResult
+				page, err = performGetRequest(test.app, client)
 				if err == nil {
-					resp = &http.Response{StatusCode: statusCode, Header: http.Header{}}
+					resp = &http.Response{StatusCode: page.statusCode, Header: http.Header{}}
+					actualContent, statusContentOk = page.actualContent, page.statusContentOk
 				}
 			} else {
 				resp, err = performHeadRequest(test.app, client)
@@ -389,13 +392,13 @@ func TestString(t *testing.T) {
 		appStatus      *AppCheckStatus
 		expectedOutput string
 	}{
-		{description: "Successful status", appStatus: &AppCheckStatus{Application: &Application{Name: "", URL: "https://library.nyu.edu", ExpectedStatusCode: http.StatusOK, Timeout: time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: "", ExpectedCSP: ""}, StatusOk: true, StatusContentOk: true, ActualStatusCode: 200}, expectedOutput: "Success: URL https://library.nyu.edu resolved with 200"},
+		{description: "Successful status", appStatus: &AppCheckStatus{Application: &Application{Name: "", URL: "https://library.nyu.edu", ExpectedStatusCode: http.StatusOK, Timeout: time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: nil, ExpectedCSP: ""}, StatusOk: true, StatusContentOk: true, ActualStatusCode: 200}, expectedOutput: "Success: URL https://library.nyu.edu resolved with 200"},
 		{description: "Failed status", appStatus: &AppCheckStatus{Application: &Application{URL: "https://library.nyu.edu", ExpectedStatusCode: http.StatusOK, Timeout: time.Second}, StatusOk: false, StatusContentOk: true, ActualStatusCode: 404}, expectedOutput: "Failure: URL https://library.nyu.edu resolved with 404, expected 200"},
 		{description: "Successful status with location", appStatus: &AppCheckStatus{Application: &Application{URL: "http://library.nyu.edu", ExpectedStatusCode: http.StatusMovedPermanently, Timeout: time.Second, ExpectedLocation: "https://library.nyu.edu/"}, StatusOk: true, StatusContentOk: true, ActualStatusCode: 301, ActualLocation: "https://library.nyu.edu/"}, expectedOutput: "Success: URL http://library.nyu.edu resolved with 301, redirect location matched https://library.nyu.edu/"},
 		{description: "Successful status, redirect present but no expected_location", appStatus: &AppCheckStatus{Application: &Application{URL: "http://library.nyu.edu", ExpectedStatusCode: http.StatusFound, Timeout: time.Second, ExpectedLocation: ""}, StatusOk: true, StatusContentOk: true, ActualStatusCode: 302, ActualLocation: "https://library.nyu.edu/"}, expectedOutput: "Success: URL http://library.nyu.edu resolved with 302"},
 		{description: "Failed status with location", appStatus: &AppCheckStatus{Application: &Application{URL: "http://library.nyu.edu", ExpectedStatusCode: http.StatusMovedPermanently, Timeout: time.Second, ExpectedLocation: "http://library.nyu.edu/"}, StatusOk: false, StatusContentOk: true, ActualStatusCode: 301, ActualLocation: "https://library.nyu.edu/"}, expectedOutput: "Failure: URL http://library.nyu.edu resolved with 301, but redirect location https://library.nyu.edu/ did not match http://library.nyu.edu/"},
-		{description: "Successful status with expected content", appStatus: &AppCheckStatus{Application: &Application{Name: "", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: "Example Domain", ExpectedCSP: ""}, StatusOk: true, StatusContentOk: true, ActualStatusCode: 200, ActualContent: "Example Domain"}, expectedOutput: "Success: URL https://example.com resolved with 200\nSuccess: ExpectedContent Example Domain matched ActualContent Example Domain"},
-		{description: "Failed status with unexpected content", appStatus: &AppCheckStatus{Application: &Application{Name: "", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: "Wrong Content", ExpectedCSP: ""}, StatusOk: true, StatusContentOk: false, ActualStatusCode: 200, ActualContent: "Example Domain"}, expectedOutput: "Success: URL https://example.com resolved with 200\nFailure: Expected content Wrong Content did not match Actual Content"},
+		{description: "Successful status with expected content", appStatus: &AppCheckStatus{Application: &Application{Name: "", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: ContentAssertions{{Value: "Example Domain"}}, ExpectedCSP: ""}, StatusOk: true, StatusContentOk: true, ActualStatusCode: 200, ActualContent: "Example Domain"}, expectedOutput: "Success: URL https://example.com resolved with 200\nSuccess: ExpectedContent Example Domain matched ActualContent Example Domain"},
+		{description: "Failed status with unexpected content", appStatus: &AppCheckStatus{Application: &Application{Name: "", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second, MaxRedirects: 0, ExpectedLocation: "", ExpectedContent: ContentAssertions{{Value: "Wrong Content"}}, ExpectedCSP: ""}, StatusOk: true, StatusContentOk: false, ActualStatusCode: 200, ActualContent: "Example Domain"}, expectedOutput: "Success: URL https://example.com resolved with 200\nFailure: Expected content Wrong Content did not match Actual Content"},
 	}
 
 	for _, test := range tests {
@@ -405,6 +408,30 @@ func TestString(t *testing.T) {
 	}
 }
 
+func TestSetIsPrimoVE(t *testing.T) {
+	var tests = []struct {
+		yamlPath string
+		want     bool
+	}{
+		{"config/primo_ve.applications.yml", true},
+		{"./config/primo_ve.applications.yml", true},
+		{"config/primo_ve.d", true},
+		{"config/primo_ve.d/", true},
+		{"config/primo_ve.d/*.yml", true},
+		{"config/primo_ve.d/discovery.yml", true},
+		{"config/prod.applications.yml", false},
+		{"config/prod.d", false},
+	}
+
+	t.Cleanup(func() { IsPrimoVE = false })
+	for _, tt := range tests {
+		t.Run(tt.yamlPath, func(t *testing.T) {
+			SetIsPrimoVE(tt.yamlPath)
+			assert.Equal(t, tt.want, IsPrimoVE)
+		})
+	}
+}
+
 func TestCompareContent(t *testing.T) {
 	var tests = []struct {
 		description string
@@ -495,7 +522,7 @@ func TestStringWithEnvVarsSuccessAndContent(t *testing.T) {
 			isPrimoVE:   false,
 			debugMode:   false,
 			appStatus: &AppCheckStatus{
-				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: false, ExpectedContent: "Example Content"},
+				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: false, ExpectedContent: ContentAssertions{{Value: "Example Content"}}},
 				StatusOk:    true, StatusContentOk: true,
 				ActualStatusCode: 200, ActualContent: "Example Content"},
 			expectedOutput: "Success: URL https://example.com resolved with 200\nSuccess: ExpectedContent Example Content matched ActualContent Example Content",
@@ -505,7 +532,7 @@ func TestStringWithEnvVarsSuccessAndContent(t *testing.T) {
 			isPrimoVE:   true,
 			debugMode:   true,
 			appStatus: &AppCheckStatus{
-				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: false, ExpectedContent: "Example Content"},
+				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: false, ExpectedContent: ContentAssertions{{Value: "Example Content"}}},
 				StatusOk:    true, StatusContentOk: true,
 				ActualStatusCode: 200, ActualContent: "Example Content"},
 			expectedOutput: "Success: URL https://example.com resolved with 200\nSuccess: ExpectedContent Example Content matched ActualContent Example Content",
@@ -515,7 +542,7 @@ func TestStringWithEnvVarsSuccessAndContent(t *testing.T) {
 			isPrimoVE:   false,
 			debugMode:   false,
 			appStatus: &AppCheckStatus{
-				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: false, ExpectedContent: "Expected Content"},
+				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: false, ExpectedContent: ContentAssertions{{Value: "Expected Content"}}},
 				StatusOk:    true, StatusContentOk: false,
 				ActualStatusCode: 200, ActualContent: "Some Actual Content"},
 			expectedOutput: "Success: URL https://example.com resolved with 200\nFailure: Expected content Expected Content did not match Actual Content",
@@ -525,7 +552,7 @@ func TestStringWithEnvVarsSuccessAndContent(t *testing.T) {
 			isPrimoVE:   false,
 			debugMode:   false,
 			appStatus: &AppCheckStatus{
-				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: true, ExpectedContent: "Expected Content"},
+				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: true, ExpectedContent: ContentAssertions{{Value: "Expected Content"}}},
 				StatusOk:    true, StatusContentOk: false,
 				ActualStatusCode: 200, ActualContent: "Some Actual Content"},
 			expectedOutput: "Success: URL https://example.com resolved with 200\nFailure: Expected content Expected Content did not match Actual Content Some Actual Content",
@@ -535,7 +562,7 @@ func TestStringWithEnvVarsSuccessAndContent(t *testing.T) {
 			isPrimoVE:   true,
 			debugMode:   true,
 			appStatus: &AppCheckStatus{
-				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: false, ExpectedContent: "Expected Content"},
+				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: false, ExpectedContent: ContentAssertions{{Value: "Expected Content"}}},
 				StatusOk:    true, StatusContentOk: false,
 				ActualStatusCode: 200, ActualContent: "Some Actual Content"},
 
@@ -546,7 +573,7 @@ func TestStringWithEnvVarsSuccessAndContent(t *testing.T) {
 			isPrimoVE:   false,
 			debugMode:   true,
 			appStatus: &AppCheckStatus{
-				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: false, ExpectedContent: "Expected Content"},
+				Application: &Application{Name: "TestApp", URL: "https://example.com", ExpectedStatusCode: http.StatusOK, Timeout: time.Second * 5, IncludeActualContentOnFailure: false, ExpectedContent: ContentAssertions{{Value: "Expected Content"}}},
 				StatusOk:    true, StatusContentOk: false,
 				ActualStatusCode: 200, ActualContent: "Some Actual Content"},
 
@@ -613,7 +640,7 @@ func TestGetStatus_RelativeRedirect_AndContentSuccess(t *testing.T) {
 		ExpectedStatusCode: http.StatusFound,
 		Timeout:            2 * time.Second,
 		ExpectedLocation:   "/mng/login",
-		ExpectedContent:    expectedSnippet,
+		ExpectedContent:    ContentAssertions{{Value: expectedSnippet}},
 	}
 
 	status := app.GetStatus()
@@ -649,7 +676,7 @@ func TestGetStatus_RelativeRedirect_AndContent_Failure_ContentMismatch(t *testin
 		ExpectedStatusCode: http.StatusFound,
 		Timeout:            2 * time.Second,
 		ExpectedLocation:   "/mng/login",
-		ExpectedContent:    expectedSnippet, // NOT present on final page
+		ExpectedContent:    ContentAssertions{{Value: expectedSnippet}}, // NOT present on final page
 	}
 
 	status := app.GetStatus()
@@ -746,7 +773,7 @@ func TestGetStatus_OversizedBody_IsCappedAtLimit(t *testing.T) {
 		URL:                srv.URL + "/",
 		ExpectedStatusCode: http.StatusOK,
 		Timeout:            5 * time.Second,
-		ExpectedContent:    marker,
+		ExpectedContent:    ContentAssertions{{Value: marker}},
 	}
 
 	status := app.GetStatus()
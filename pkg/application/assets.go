@@ -0,0 +1,320 @@
+package application
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// Kinds of assets checked by check_assets
+const (
+	assetScript     = "script"
+	assetStylesheet = "stylesheet"
+	assetImage      = "image"
+)
+
+const (
+	// maxAssets caps how many referenced assets of a page are fetched.
+	maxAssets = 50
+	// assetWorkers is how many assets of a page are fetched at the same time.
+	assetWorkers = 4
+)
+
+// sriAlgorithms are the integrity hash algorithms, strongest first.
+var sriAlgorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"sha512", sha512.New},
+	{"sha384", sha512.New384},
+	{"sha256", sha256.New},
+}
+
+// asset is a script, stylesheet or image referenced by a page.
+type asset struct {
+	Kind      string
+	URL       string
+	Integrity string
+}
+
+// AssetResult is the outcome of fetching one referenced asset.
+type AssetResult struct {
+	Kind           string
+	URL            string
+	StatusCode     int
+	ContentType    string
+	IntegrityOk    bool // set when the asset has an integrity attribute that matched
+	Ok             bool
+	FailureDetails string
+}
+
+// checkAssets fetches the scripts, stylesheets and images the final page references from its own
+// origin or from asset_hosts, and checks their status, content type and integrity attribute.
+func (test Application) checkAssets(client *http.Client, page *pageResult) []AssetResult {
+	if !test.CheckAssets {
+		return nil
+	}
+	pageURL, err := url.Parse(page.finalURL)
+	if err != nil {
+		return []AssetResult{{Kind: "page", URL: page.finalURL, FailureDetails: fmt.Sprintf("invalid page URL: %v", err)}}
+	}
+
+	assets := findAssets(pageURL, page.body, test.AssetHosts)
+	if len(assets) > maxAssets {
+		log.Printf("[%s] page references %d assets, checking the first %d", test.Name, len(assets), maxAssets)
+		assets = assets[:maxAssets]
+	}
+
+	results := make([]AssetResult, len(assets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(assetWorkers, len(assets)) {
+		wg.Go(func() {
+			for i := range jobs {
+				results[i] = test.checkAsset(client, pageURL, assets[i])
+			}
+		})
+	}
+	for i := range assets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// findAssets returns the distinct assets referenced by the page that are same-origin or on one of hosts,
+// resolved against the page URL or its <base href>.
+func findAssets(pageURL *url.URL, body string, hosts []string) []asset {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	base := pageURL
+	var assets []asset
+	seen := map[string]bool{}
+	add := func(kind, ref, integrity string) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" || strings.HasPrefix(ref, "data:") {
+			return
+		}
+		resolved, err := base.Parse(ref)
+		if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+			return
+		}
+		resolved.Fragment = ""
+		if !sameOrigin(pageURL, resolved) && !slices.ContainsFunc(hosts, func(host string) bool {
+			return strings.EqualFold(host, resolved.Hostname())
+		}) {
+			return
+		}
+		if seen[resolved.String()] {
+			return
+		}
+		seen[resolved.String()] = true
+		assets = append(assets, asset{Kind: kind, URL: resolved.String(), Integrity: integrity})
+	}
+
+	for node := range doc.Descendants() {
+		if node.Type != html.ElementNode {
+			continue
+		}
+		switch node.Data {
+		case "base":
+			if href := attribute(node, "href"); href != "" && base == pageURL {
+				if parsed, err := pageURL.Parse(href); err == nil {
+					base = parsed
+				}
+			}
+		case "script":
+			add(assetScript, attribute(node, "src"), attribute(node, "integrity"))
+		case "link":
+			if slices.Contains(strings.Fields(strings.ToLower(attribute(node, "rel"))), "stylesheet") {
+				add(assetStylesheet, attribute(node, "href"), attribute(node, "integrity"))
+			}
+		case "img":
+			add(assetImage, attribute(node, "src"), "")
+		}
+	}
+	return assets
+}
+
+func attribute(node *html.Node, name string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func sameOrigin(a, b *url.URL) bool {
+	return a.Scheme == b.Scheme && strings.EqualFold(a.Host, b.Host)
+}
+
+// checkAsset fetches one asset, following up to max_redirects redirects as browsers would.
+// Same-origin assets are requested with the check's credentials.
+func (test Application) checkAsset(client *http.Client, pageURL *url.URL, asset asset) AssetResult {
+	result := AssetResult{Kind: asset.Kind, URL: asset.URL}
+
+	maxRedirects := test.redirectLimit()
+	assetClient := *client
+	assetClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		test.Auth.stripOnCrossHostRedirect(req, via)
+		return nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, asset.URL, nil)
+	if err != nil {
+		result.FailureDetails = err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if test.Auth != nil && sameOrigin(pageURL, req.URL) {
+		if err := test.Auth.apply(req, test.tokenClient); err != nil {
+			result.FailureDetails = err.Error()
+			return result
+		}
+	}
+	result.URL = test.Auth.redactURL(req.URL)
+
+	resp, err := doRequest(test, &assetClient, req)
+	if err != nil {
+		result.FailureDetails = fmt.Sprintf("failed: %v", err)
+		return result
+	}
+	defer closeResponseBody(resp.Body)
+
+	result.StatusCode = resp.StatusCode
+	result.ContentType = resp.Header.Get("Content-Type")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		result.FailureDetails = fmt.Sprintf("resolved with %d", resp.StatusCode)
+		return result
+	}
+	if !contentTypeMatches(asset.Kind, result.ContentType) {
+		result.FailureDetails = fmt.Sprintf("has content type %s, expected %s", result.ContentType, expectedContentType(asset.Kind))
+		return result
+	}
+
+	if asset.Integrity != "" {
+		buf := new(bytes.Buffer)
+		if _, err := io.Copy(buf, io.LimitReader(resp.Body, maxResponseBodyBytes)); err != nil {
+			result.FailureDetails = fmt.Sprintf("reading response: %v", err)
+			return result
+		}
+		ok, checked := verifyIntegrity(buf.Bytes(), asset.Integrity)
+		if checked && !ok {
+			result.FailureDetails = fmt.Sprintf("does not match integrity %s", asset.Integrity)
+			return result
+		}
+		result.IntegrityOk = checked
+	}
+	result.Ok = true
+	return result
+}
+
+// contentTypeMatches reports whether a Content-Type suits the kind of asset. A missing
+// Content-Type is accepted, since browsers sniff it.
+func contentTypeMatches(kind, contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch kind {
+	case assetScript:
+		return strings.Contains(mediaType, "javascript") || strings.Contains(mediaType, "ecmascript")
+	case assetStylesheet:
+		return mediaType == "text/css"
+	default:
+		return strings.HasPrefix(mediaType, "image/")
+	}
+}
+
+func expectedContentType(kind string) string {
+	switch kind {
+	case assetScript:
+		return "JavaScript"
+	case assetStylesheet:
+		return "text/css"
+	default:
+		return "an image"
+	}
+}
+
+// verifyIntegrity checks data against an integrity attribute as browsers do: only the hashes of
+// the strongest algorithm listed count, and any one of them may match. checked is false when
+// the attribute lists no supported algorithm.
+func verifyIntegrity(data []byte, integrity string) (ok, checked bool) {
+	hashes := map[string][]string{}
+	for _, token := range strings.Fields(integrity) {
+		algorithm, digest, found := strings.Cut(token, "-")
+		if !found {
+			continue
+		}
+		// Options after the digest (e.g. "sha384-...?ct=application/javascript") are ignored.
+		digest, _, _ = strings.Cut(digest, "?")
+		hashes[strings.ToLower(algorithm)] = append(hashes[strings.ToLower(algorithm)], digest)
+	}
+
+	for _, algorithm := range sriAlgorithms {
+		expected, listed := hashes[algorithm.name]
+		if !listed {
+			continue
+		}
+		h := algorithm.new()
+		h.Write(data)
+		actual := base64.StdEncoding.EncodeToString(h.Sum(nil))
+		return slices.Contains(expected, actual), true
+	}
+	return false, false
+}
+
+func (result AssetResult) passed() bool {
+	return result.Ok
+}
+
+// assetResultsStrings summarizes the referenced assets in one line, followed by a line per broken asset.
+func assetResultsStrings(results []AssetResult) []string {
+	var broken []string
+	verified := 0
+	for _, result := range results {
+		if result.IntegrityOk {
+			verified++
+		}
+		if !result.Ok {
+			broken = append(broken, fmt.Sprintf("Failure: Asset %s %s %s", result.Kind, result.URL, result.FailureDetails))
+		}
+	}
+
+	integrity := ""
+	if verified > 0 {
+		integrity = fmt.Sprintf(", %d with matching integrity", verified)
+	}
+	if len(results) == 0 {
+		return []string{"Success: Assets page references no assets to check"}
+	}
+	if len(broken) == 0 {
+		return []string{fmt.Sprintf("Success: Assets all %d referenced assets loaded%s", len(results), integrity)}
+	}
+	return append([]string{fmt.Sprintf("Failure: Assets %d of %d referenced assets are broken", len(broken), len(results))}, broken...)
+}
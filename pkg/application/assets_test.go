@@ -0,0 +1,212 @@
+package application
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const bundleJS = "angular.module('viewCustom', []);"
+
+func sri384(data string) string {
+	sum := sha512.Sum384([]byte(data))
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func sri256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return "sha256-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestAssetsUnmarshalYAML(t *testing.T) {
+	const config = `
+name: primo-ve
+url: 'https://search.library.nyu.edu/discovery/search?vid=01NYU_INST:NYU'
+expected_status: 200
+check_assets: true
+asset_hosts: ['cdn.library.nyu.edu']
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+
+	assert.True(t, app.CheckAssets)
+	assert.Equal(t, []string{"cdn.library.nyu.edu"}, app.AssetHosts)
+	assert.True(t, app.IsGet(), "assets are found in the response body")
+}
+
+func TestFindAssets(t *testing.T) {
+	pageURL, err := url.Parse("https://search.library.nyu.edu/discovery/search?vid=01NYU_INST:NYU")
+	require.NoError(t, err)
+
+	const page = `<html><head>
+<base href="/discovery/">
+<script src="custom/01NYU_INST-NYU/js/custom.js" integrity="sha384-abc"></script>
+<script src="custom/01NYU_INST-NYU/js/custom.js"></script>
+<script>inline()</script>
+<script src="https://cdn.library.nyu.edu/primo-customization/bundle.js"></script>
+<script src="https://www.googletagmanager.com/gtag/js"></script>
+<link rel="stylesheet" href="/discovery/custom/01NYU_INST-NYU/css/custom1.css">
+<link rel="icon" href="/favicon.ico">
+</head><body>
+<img src="lib/img/logo.png#top">
+<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+</body></html>`
+
+	assets := findAssets(pageURL, page, []string{"CDN.library.nyu.edu"})
+
+	assert.Equal(t, []asset{
+		{Kind: assetScript, URL: "https://search.library.nyu.edu/discovery/custom/01NYU_INST-NYU/js/custom.js", Integrity: "sha384-abc"},
+		{Kind: assetScript, URL: "https://cdn.library.nyu.edu/primo-customization/bundle.js"},
+		{Kind: assetStylesheet, URL: "https://search.library.nyu.edu/discovery/custom/01NYU_INST-NYU/css/custom1.css"},
+		{Kind: assetImage, URL: "https://search.library.nyu.edu/discovery/lib/img/logo.png"},
+	}, assets)
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	var tests = []struct {
+		description string
+		integrity   string
+		wantOk      bool
+		wantChecked bool
+	}{
+		{"sha384 matches", sri384(bundleJS), true, true},
+		{"sha256 matches", sri256(bundleJS), true, true},
+		{"Does not match", sri384("something else"), false, true},
+		{"Any hash of the strongest algorithm", sri384("v1") + " " + sri384(bundleJS), true, true},
+		{"Only the strongest algorithm counts", sri256(bundleJS) + " " + sri384("v1"), false, true},
+		{"Options are ignored", sri384(bundleJS) + "?ct=application/javascript", true, true},
+		{"Unsupported algorithm", "md5-1B2M2Y8AsgTpgAmY7PhCfg==", false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			ok, checked := verifyIntegrity([]byte(bundleJS), test.integrity)
+			assert.Equal(t, test.wantOk, ok)
+			assert.Equal(t, test.wantChecked, checked)
+		})
+	}
+}
+
+func TestGetStatus_CheckAssets(t *testing.T) {
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		fmt.Fprint(w, bundleJS)
+	}))
+	t.Cleanup(cdn.Close)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/discovery/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><head>
+<script src="/discovery/custom/bundle.js" integrity="%s"></script>
+<script src="/discovery/custom/tampered.js" integrity="%s"></script>
+<script src="%s/primo-customization/bundle.js"></script>
+<link rel="stylesheet" href="/discovery/custom/custom1.css">
+</head><body><img src="/discovery/img/missing.png"></body></html>`, sri384(bundleJS), sri384(bundleJS), cdn.URL)
+	})
+	mux.HandleFunc("/discovery/custom/bundle.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		fmt.Fprint(w, bundleJS)
+	})
+	mux.HandleFunc("/discovery/custom/tampered.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript")
+		fmt.Fprint(w, bundleJS+"\nalert(1);")
+	})
+	mux.HandleFunc("/discovery/custom/custom1.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html>Not Found</html>")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "primo-ve",
+		URL:                srv.URL + "/discovery/search",
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		CheckAssets:        true,
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk)
+	assert.False(t, status.IsOk(), "broken assets fail the check")
+	require.Len(t, status.AssetResults, 4, "the other origin's script is not fetched without asset_hosts")
+	assert.Equal(t, "Success: URL "+srv.URL+"/discovery/search resolved with 200\n"+
+		"Failure: Assets 3 of 4 referenced assets are broken\n"+
+		"Failure: Asset script "+srv.URL+"/discovery/custom/tampered.js does not match integrity "+sri384(bundleJS)+"\n"+
+		"Failure: Asset stylesheet "+srv.URL+"/discovery/custom/custom1.css has content type text/html; charset=utf-8, expected text/css\n"+
+		"Failure: Asset image "+srv.URL+"/discovery/img/missing.png resolved with 404",
+		outputWithoutTimings(status))
+
+	// Both test servers listen on 127.0.0.1, so allow-listing it adds the CDN's script.
+	app.AssetHosts = []string{"127.0.0.1"}
+	status = app.GetStatus()
+
+	require.NotNil(t, status)
+	require.Len(t, status.AssetResults, 5)
+	assert.Equal(t, AssetResult{Kind: assetScript, URL: cdn.URL + "/primo-customization/bundle.js", StatusCode: http.StatusOK, ContentType: "application/javascript", Ok: true},
+		status.AssetResults[2])
+	assert.Contains(t, status.String(), "Failure: Assets 3 of 5 referenced assets are broken")
+}
+
+func TestGetStatus_CheckAssetsFollowsRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/discovery/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head>
+<script src="/discovery/custom/bundle.js"></script>
+<script src="/discovery/custom/loop.js"></script>
+</head></html>`)
+	})
+	mux.HandleFunc("/discovery/custom/bundle.js", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/discovery/custom/v2/bundle.js", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/discovery/custom/v2/bundle.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript")
+		fmt.Fprint(w, bundleJS)
+	})
+	mux.HandleFunc("/discovery/custom/loop.js", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/discovery/custom/loop.js", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "primo-ve",
+		URL:                srv.URL + "/discovery/search",
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		MaxRedirects:       3,
+		CheckAssets:        true,
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	require.Len(t, status.AssetResults, 2)
+	assert.Equal(t, AssetResult{Kind: assetScript, URL: srv.URL + "/discovery/custom/bundle.js", StatusCode: http.StatusOK, ContentType: "text/javascript", Ok: true},
+		status.AssetResults[0], "a redirected asset is checked where it lands")
+	assert.False(t, status.AssetResults[1].Ok)
+	assert.Contains(t, status.AssetResults[1].FailureDetails, "stopped after 3 redirects")
+}
+
+func TestAssetResultsStrings_AllLoaded(t *testing.T) {
+	results := []AssetResult{
+		{Kind: assetScript, URL: "https://search.library.nyu.edu/discovery/custom/bundle.js", IntegrityOk: true, Ok: true},
+		{Kind: assetImage, URL: "https://search.library.nyu.edu/discovery/img/logo.png", Ok: true},
+	}
+
+	assert.True(t, allOk(results))
+	assert.Equal(t, []string{"Success: Assets all 2 referenced assets loaded, 1 with matching integrity"}, assetResultsStrings(results))
+	assert.Equal(t, []string{"Success: Assets page references no assets to check"}, assetResultsStrings(nil))
+}
@@ -0,0 +1,300 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported values for Auth.Type
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthAPIKey = "api_key"
+	AuthOAuth2 = "oauth2"
+)
+
+const (
+	// redacted replaces secret values anywhere they could otherwise be printed.
+	redacted = "REDACTED"
+	// tokenExpirySkew renews cached OAuth2 tokens slightly before they actually expire.
+	tokenExpirySkew = 30 * time.Second
+	// maxTokenResponseBytes caps how much of an OAuth2 token response is read.
+	maxTokenResponseBytes = 1 << 20 // 1 MiB
+	// defaultTokenTimeout bounds an OAuth2 token request when the check has no timeout.
+	defaultTokenTimeout = 30 * time.Second
+)
+
+// Secret is a credential read at request time from an environment variable or a mounted file.
+// Secret values are never stored in the config, so they cannot leak through it.
+type Secret struct {
+	Env  string `yaml:"env"`
+	File string `yaml:"file"`
+}
+
+// Auth configures how a synthetic test authenticates against the application.
+type Auth struct {
+	Type string `yaml:"type"`
+	// basic
+	Username string `yaml:"username"`
+	Password Secret `yaml:"password"`
+	// bearer
+	Token Secret `yaml:"token"`
+	// api_key: sent in Header (with an optional Prefix such as "apikey ") or as QueryParam
+	Key        Secret `yaml:"key"`
+	Header     string `yaml:"header"`
+	Prefix     string `yaml:"prefix"`
+	QueryParam string `yaml:"query_param"`
+	// oauth2 client credentials
+	TokenURL     string   `yaml:"token_url"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret Secret   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+// Value resolves the secret, preferring the environment variable over the file.
+// Errors name where the secret was expected, never its value.
+func (secret Secret) Value() (string, error) {
+	switch {
+	case secret.Env != "":
+		value, ok := os.LookupEnv(secret.Env)
+		if !ok || value == "" {
+			return "", fmt.Errorf("environment variable %s is not set", secret.Env)
+		}
+		return value, nil
+	case secret.File != "":
+		data, err := os.ReadFile(secret.File)
+		if err != nil {
+			return "", fmt.Errorf("cannot read secret file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		return "", errors.New("secret has neither env nor file configured")
+	}
+}
+
+// String never reveals the secret value, only where it comes from.
+func (secret Secret) String() string {
+	switch {
+	case secret.Env != "":
+		return "env:" + secret.Env
+	case secret.File != "":
+		return "file:" + secret.File
+	default:
+		return "unset"
+	}
+}
+
+// apply adds the configured credentials to the request. tokenClient builds the client an
+// OAuth2 token is requested with, when one has to be requested.
+func (auth *Auth) apply(req *http.Request, tokenClient func() (*http.Client, error)) error {
+	switch auth.Type {
+	case AuthBasic:
+		password, err := auth.Password.Value()
+		if err != nil {
+			return fmt.Errorf("auth basic password: %w", err)
+		}
+		req.SetBasicAuth(auth.Username, password)
+	case AuthBearer:
+		token, err := auth.Token.Value()
+		if err != nil {
+			return fmt.Errorf("auth bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case AuthAPIKey:
+		key, err := auth.Key.Value()
+		if err != nil {
+			return fmt.Errorf("auth api_key key: %w", err)
+		}
+		switch {
+		case auth.QueryParam != "":
+			query := req.URL.Query()
+			query.Set(auth.QueryParam, key)
+			req.URL.RawQuery = query.Encode()
+		case auth.Header != "":
+			req.Header.Set(auth.Header, auth.Prefix+key)
+		default:
+			return errors.New("auth api_key requires header or query_param")
+		}
+	case AuthOAuth2:
+		token, err := auth.oauth2Token(tokenClient)
+		if err != nil {
+			return fmt.Errorf("auth oauth2: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	default:
+		return fmt.Errorf("unsupported auth type %q", auth.Type)
+	}
+	return nil
+}
+
+// redactURL returns the URL as a string with any API key query parameter masked.
+func (auth *Auth) redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	if auth == nil || auth.Type != AuthAPIKey || auth.QueryParam == "" {
+		return u.String()
+	}
+	query := u.Query()
+	if !query.Has(auth.QueryParam) {
+		return u.String()
+	}
+	query.Set(auth.QueryParam, redacted)
+	masked := *u
+	masked.RawQuery = query.Encode()
+	return masked.String()
+}
+
+// oauth2Token is a cached access token obtained with the client credentials grant.
+type oauth2Token struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+// tokenFetch is a token request in flight. done is closed once token and err are set.
+type tokenFetch struct {
+	done  chan struct{}
+	token oauth2Token
+	err   error
+}
+
+// tokenCache shares OAuth2 access tokens across checks so each run requests a token
+// once per token endpoint, client and scope set rather than once per check. Tokens are
+// requested without holding the lock; checks that need a token already being requested
+// wait for that request instead of sending their own.
+var tokenCache = struct {
+	sync.Mutex
+	tokens   map[string]oauth2Token
+	fetching map[string]*tokenFetch
+}{tokens: make(map[string]oauth2Token), fetching: make(map[string]*tokenFetch)}
+
+func (auth *Auth) tokenCacheKey() string {
+	return strings.Join([]string{auth.TokenURL, auth.ClientID, strings.Join(auth.Scopes, " ")}, "|")
+}
+
+// oauth2Token returns a cached access token, requesting a new one when none is cached or it is about to expire.
+func (auth *Auth) oauth2Token(tokenClient func() (*http.Client, error)) (string, error) {
+	key := auth.tokenCacheKey()
+
+	tokenCache.Lock()
+	if cached, ok := tokenCache.tokens[key]; ok && time.Now().Before(cached.expiresAt) {
+		tokenCache.Unlock()
+		return cached.accessToken, nil
+	}
+	fetch, inFlight := tokenCache.fetching[key]
+	if !inFlight {
+		fetch = &tokenFetch{done: make(chan struct{})}
+		tokenCache.fetching[key] = fetch
+	}
+	tokenCache.Unlock()
+
+	if inFlight {
+		<-fetch.done
+	} else {
+		fetch.token, fetch.err = auth.requestOAuth2Token(tokenClient)
+		tokenCache.Lock()
+		delete(tokenCache.fetching, key)
+		if fetch.err == nil {
+			tokenCache.tokens[key] = fetch.token
+		}
+		tokenCache.Unlock()
+		close(fetch.done)
+	}
+
+	if fetch.err != nil {
+		return "", fetch.err
+	}
+	return fetch.token.accessToken, nil
+}
+
+// stripOnCrossHostRedirect removes an api_key header from a redirected request to another host.
+// The client only removes Authorization and Cookie headers itself, so a custom header would
+// otherwise send the key to whichever host the application redirects to.
+func (auth *Auth) stripOnCrossHostRedirect(req *http.Request, via []*http.Request) {
+	if auth == nil || auth.Type != AuthAPIKey || auth.Header == "" || len(via) == 0 {
+		return
+	}
+	if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+		req.Header.Del(auth.Header)
+	}
+}
+
+// dropRejectedToken forgets the cached OAuth2 token req was sent with once the application
+// rejected it with a 401, so the next check or retry requests a new one. A token cached by
+// another check in the meantime is kept.
+func (auth *Auth) dropRejectedToken(req *http.Request, resp *http.Response) {
+	if auth == nil || auth.Type != AuthOAuth2 || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return
+	}
+	rejected := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	key := auth.tokenCacheKey()
+
+	tokenCache.Lock()
+	defer tokenCache.Unlock()
+	if cached, ok := tokenCache.tokens[key]; ok && cached.accessToken == rejected {
+		delete(tokenCache.tokens, key)
+	}
+}
+
+// requestOAuth2Token performs the client credentials grant against TokenURL with the client
+// tokenClient builds.
+func (auth *Auth) requestOAuth2Token(tokenClient func() (*http.Client, error)) (oauth2Token, error) {
+	clientSecret, err := auth.ClientSecret.Value()
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("client_secret: %w", err)
+	}
+	client, err := tokenClient()
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	defer closeIdleConnections(client)
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(clientSecret))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	defer closeResponseBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return oauth2Token{}, fmt.Errorf("token endpoint %s responded with %d", auth.TokenURL, resp.StatusCode)
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTokenResponseBytes)).Decode(&payload); err != nil {
+		return oauth2Token{}, fmt.Errorf("cannot decode token response: %w", err)
+	}
+	if payload.AccessToken == "" {
+		return oauth2Token{}, errors.New("token response has no access_token")
+	}
+
+	token := oauth2Token{accessToken: payload.AccessToken, expiresAt: time.Now().Add(time.Hour)}
+	if payload.ExpiresIn > 0 {
+		token.expiresAt = time.Now().Add(time.Duration(payload.ExpiresIn)*time.Second - tokenExpirySkew)
+	}
+	return token, nil
+}
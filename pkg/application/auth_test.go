@@ -0,0 +1,348 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecretEnv = "ASWA_TEST_SECRET"
+
+func TestSecretValue(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0o600))
+
+	var tests = []struct {
+		description string
+		env         string
+		secret      Secret
+		want        string
+		wantErr     string
+	}{
+		{"From environment variable", "from-env", Secret{Env: testSecretEnv}, "from-env", ""},
+		{"Environment variable wins over file", "from-env", Secret{Env: testSecretEnv, File: secretFile}, "from-env", ""},
+		{"From mounted file, trailing newline trimmed", "", Secret{File: secretFile}, "from-file", ""},
+		{"Unset environment variable", "", Secret{Env: testSecretEnv}, "", "environment variable ASWA_TEST_SECRET is not set"},
+		{"Missing file", "", Secret{File: secretFile + ".missing"}, "", "cannot read secret file"},
+		{"Nothing configured", "", Secret{}, "", "secret has neither env nor file configured"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			t.Setenv(testSecretEnv, test.env)
+			got, err := test.secret.Value()
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestAuthApply(t *testing.T) {
+	t.Setenv(testSecretEnv, "s3cr3t")
+
+	var tests = []struct {
+		description string
+		auth        Auth
+		wantHeader  map[string]string
+		wantQuery   map[string]string
+		wantErr     string
+	}{
+		{
+			description: "Basic",
+			auth:        Auth{Type: AuthBasic, Username: "aswa", Password: Secret{Env: testSecretEnv}},
+			wantHeader:  map[string]string{"Authorization": "Basic YXN3YTpzM2NyM3Q="},
+		},
+		{
+			description: "Bearer",
+			auth:        Auth{Type: AuthBearer, Token: Secret{Env: testSecretEnv}},
+			wantHeader:  map[string]string{"Authorization": "Bearer s3cr3t"},
+		},
+		{
+			description: "API key header with prefix",
+			auth:        Auth{Type: AuthAPIKey, Key: Secret{Env: testSecretEnv}, Header: "Authorization", Prefix: "apikey "},
+			wantHeader:  map[string]string{"Authorization": "apikey s3cr3t"},
+		},
+		{
+			description: "API key query parameter",
+			auth:        Auth{Type: AuthAPIKey, Key: Secret{Env: testSecretEnv}, QueryParam: "apikey"},
+			wantQuery:   map[string]string{"apikey": "s3cr3t", "vid": "01NYU_INST:NYU"},
+		},
+		{
+			description: "API key without destination",
+			auth:        Auth{Type: AuthAPIKey, Key: Secret{Env: testSecretEnv}},
+			wantErr:     "auth api_key requires header or query_param",
+		},
+		{
+			description: "Missing secret",
+			auth:        Auth{Type: AuthBearer, Token: Secret{Env: testSecretEnv + "_UNSET"}},
+			wantErr:     "auth bearer token: environment variable ASWA_TEST_SECRET_UNSET is not set",
+		},
+		{
+			description: "Unsupported type",
+			auth:        Auth{Type: "digest"},
+			wantErr:     `unsupported auth type "digest"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "https://api.example.com/almaws?vid=01NYU_INST:NYU", nil)
+			require.NoError(t, err)
+
+			err = test.auth.apply(req, Application{Timeout: time.Second}.tokenClient)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			for name, value := range test.wantHeader {
+				assert.Equal(t, value, req.Header.Get(name))
+			}
+			for name, value := range test.wantQuery {
+				assert.Equal(t, value, req.URL.Query().Get(name))
+			}
+		})
+	}
+}
+
+func TestAuthOAuth2_TokenIsCachedAcrossChecks(t *testing.T) {
+	t.Setenv(testSecretEnv, "client-secret")
+
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "aswa" || clientSecret != "client-secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token-" + r.FormValue("scope"), "expires_in": 3600})
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-read" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(apiServer.Close)
+
+	auth := &Auth{Type: AuthOAuth2, TokenURL: tokenServer.URL, ClientID: "aswa", ClientSecret: Secret{Env: testSecretEnv}, Scopes: []string{"read"}}
+	for _, name := range []string{"libcal-api", "libguides-api", "libcal-api-again"} {
+		app := &Application{Name: name, URL: apiServer.URL, ExpectedStatusCode: http.StatusOK, Timeout: time.Second, Auth: auth}
+		status := app.GetStatus()
+		assert.True(t, status.StatusOk, "%s should authenticate with the cached token", name)
+	}
+
+	assert.Equal(t, int32(1), tokenRequests.Load(), "the token should be requested once and reused")
+}
+
+func TestAuthOAuth2_ConcurrentChecksShareOneTokenRequest(t *testing.T) {
+	t.Setenv(testSecretEnv, "client-secret")
+
+	var tokenRequests atomic.Int32
+	release := make(chan struct{})
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "shared", "expires_in": 3600})
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	auth := &Auth{Type: AuthOAuth2, TokenURL: tokenServer.URL, ClientID: "aswa", ClientSecret: Secret{Env: testSecretEnv}}
+	var wg sync.WaitGroup
+	tokens := make([]string, 5)
+	for i := range tokens {
+		wg.Go(func() {
+			token, err := auth.oauth2Token(Application{Timeout: time.Second}.tokenClient)
+			assert.NoError(t, err)
+			tokens[i] = token
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), tokenRequests.Load(), "concurrent checks should wait for the one token request")
+	assert.Equal(t, []string{"shared", "shared", "shared", "shared", "shared"}, tokens)
+}
+
+func TestAuthOAuth2_RejectedTokenIsRequestedAgain(t *testing.T) {
+	t.Setenv(testSecretEnv, "client-secret")
+
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := tokenRequests.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": fmt.Sprintf("token-%d", n), "expires_in": 3600})
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	// The first token is revoked before it expires.
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(apiServer.Close)
+
+	auth := &Auth{Type: AuthOAuth2, TokenURL: tokenServer.URL, ClientID: "aswa", ClientSecret: Secret{Env: testSecretEnv}}
+	app := &Application{Name: "libcal-api", URL: apiServer.URL, ExpectedStatusCode: http.StatusOK, Timeout: time.Second, Retries: 1, RetryBackoff: time.Millisecond, Auth: auth}
+	status := app.GetStatus()
+
+	assert.True(t, status.IsOk(), "the retry should authenticate with a new token")
+	assert.Equal(t, int32(2), tokenRequests.Load())
+}
+
+func TestAuthOAuth2_TokenEndpointUsesTLSSettings(t *testing.T) {
+	t.Setenv(testSecretEnv, "client-secret")
+
+	tokenServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "internal-token", "expires_in": 3600})
+	}))
+	t.Cleanup(tokenServer.Close)
+	apiServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer internal-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(apiServer.Close)
+
+	auth := &Auth{Type: AuthOAuth2, TokenURL: tokenServer.URL, ClientID: "internal-ca", ClientSecret: Secret{Env: testSecretEnv}}
+	// Both test servers present the same certificate, so one CA bundle trusts both.
+	app := &Application{Name: "archivesspace-api", URL: apiServer.URL, ExpectedStatusCode: http.StatusOK, Timeout: time.Second,
+		Auth: auth, TLS: &TLSSettings{CABundle: writeServerCA(t, tokenServer)}}
+
+	status := app.GetStatus()
+
+	assert.True(t, status.StatusOk, "the token endpoint should be trusted through the check's ca_bundle")
+
+	_, err := auth.requestOAuth2Token(Application{Timeout: time.Second}.tokenClient)
+	assert.ErrorContains(t, err, "certificate", "without the ca_bundle the token endpoint is not trusted")
+}
+
+func TestAuthOAuth2_TokenEndpointFailure(t *testing.T) {
+	t.Setenv(testSecretEnv, "wrong")
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	auth := &Auth{Type: AuthOAuth2, TokenURL: tokenServer.URL, ClientID: "rejected", ClientSecret: Secret{Env: testSecretEnv}}
+	_, err := auth.oauth2Token(Application{Timeout: time.Second}.tokenClient)
+	assert.ErrorContains(t, err, "responded with 401")
+}
+
+func TestAuthAPIKey_HeaderIsNotSentToOtherHosts(t *testing.T) {
+	t.Setenv(testSecretEnv, "api-key")
+
+	var mu sync.Mutex
+	var otherHostKeys []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		otherHostKeys = append(otherHostKeys, r.Header.Get("X-Api-Key"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/javascript")
+		fmt.Fprint(w, "console.log('cdn')")
+	}))
+	t.Cleanup(other.Close)
+
+	var sameHostKeys []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/hours", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/api/v2/hours", http.StatusFound)
+	})
+	mux.HandleFunc("/api/v2/hours", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sameHostKeys = append(sameHostKeys, r.Header.Get("X-Api-Key"))
+		mu.Unlock()
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `<html><script src="/static/app.js"></script></html>`)
+		}
+	})
+	mux.HandleFunc("/api/login", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/landing", http.StatusFound)
+	})
+	mux.HandleFunc("/static/app.js", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/app.js", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	auth := &Auth{Type: AuthAPIKey, Header: "X-Api-Key", Key: Secret{Env: testSecretEnv}}
+	apps := []*Application{
+		{Name: "libcal-api", URL: srv.URL + "/api/hours", ExpectedStatusCode: http.StatusOK, Timeout: time.Second, CheckAssets: true, Auth: auth},
+		{Name: "libcal-login", URL: srv.URL + "/api/login", ExpectedStatusCode: http.StatusFound, ExpectedFinalURL: other.URL + "/landing", Timeout: time.Second, Auth: auth},
+		{Name: "libcal-steps", Timeout: time.Second, Auth: auth, Steps: []Step{{URL: srv.URL + "/api/login", ExpectedStatusCode: http.StatusOK}}},
+	}
+	for _, app := range apps {
+		app.GetStatus()
+	}
+
+	assert.NotEmpty(t, sameHostKeys)
+	for _, key := range sameHostKeys {
+		assert.Equal(t, "api-key", key, "a redirect on the same host keeps the key")
+	}
+	assert.Len(t, otherHostKeys, 3, "the page, an asset and a step are each redirected to the other host")
+	for _, key := range otherHostKeys {
+		assert.Empty(t, key, "a redirect to another host drops the key")
+	}
+}
+
+func TestAuthSecretsAreNeverPrinted(t *testing.T) {
+	const secret = "do-not-print-me"
+	t.Setenv(testSecretEnv, secret)
+
+	// Nothing listens on this address, so the request fails with a transport error
+	// whose message would normally include the full request URL.
+	listener := httptest.NewServer(http.NotFoundHandler())
+	closedURL := listener.URL
+	listener.Close()
+
+	app := &Application{
+		Name:               "primo-ve-api",
+		URL:                closedURL + "/primaws/rest/pub/pnxs?q=any,contains,hamlet",
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            time.Second,
+		Auth:               &Auth{Type: AuthAPIKey, Key: Secret{Env: testSecretEnv}, QueryParam: "apikey"},
+	}
+
+	status := app.GetStatus()
+
+	require.NotEmpty(t, status.Error)
+	assert.NotContains(t, status.Error, secret)
+	assert.Contains(t, status.Error, "apikey="+redacted)
+	assert.NotContains(t, status.String(), secret)
+}
+
+func TestRedactURL(t *testing.T) {
+	u, err := url.Parse("https://api.example.com/almaws?apikey=s3cr3t&vid=NYU")
+	require.NoError(t, err)
+
+	var nilAuth *Auth
+	assert.Equal(t, u.String(), nilAuth.redactURL(u), "no auth leaves the URL untouched")
+	assert.Equal(t, u.String(), (&Auth{Type: AuthBearer}).redactURL(u))
+	assert.Equal(t, "https://api.example.com/almaws?apikey=REDACTED&vid=NYU", (&Auth{Type: AuthAPIKey, QueryParam: "apikey"}).redactURL(u))
+	assert.Equal(t, "", nilAuth.redactURL(nil))
+}
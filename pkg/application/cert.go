@@ -0,0 +1,163 @@
+package application
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kinds of certificate assertion.
+const (
+	certExpiry   = "expiry"
+	certIssuer   = "issuer"
+	certHostname = "hostname"
+	certInvalid  = "invalid" // the handshake rejected the certificate
+	certNoTLS    = "no_tls"  // certificate assertions are configured but the URL did not use TLS
+)
+
+// CertificateInfo summarizes one certificate of the chain presented by the server.
+type CertificateInfo struct {
+	Subject  string
+	SANs     []string
+	Issuer   string
+	NotAfter time.Time
+}
+
+// CertResult is the outcome of one certificate assertion on the original URL's host.
+type CertResult struct {
+	Kind     string
+	Host     string
+	Ok       bool
+	Expected string
+	Actual   string
+}
+
+// hasCertAssertions reports whether the check asserts anything about the server certificate.
+func (test Application) hasCertAssertions() bool {
+	return test.MinCertDaysRemaining > 0 || test.ExpectedCertIssuer != "" || len(test.ExpectedCertHostnames) > 0
+}
+
+// certificateChain records the peer certificates of a TLS connection, leaf first.
+func certificateChain(state *tls.ConnectionState) []CertificateInfo {
+	if state == nil {
+		return nil
+	}
+	chain := make([]CertificateInfo, 0, len(state.PeerCertificates))
+	for _, cert := range state.PeerCertificates {
+		chain = append(chain, CertificateInfo{
+			Subject:  cert.Subject.String(),
+			SANs:     cert.DNSNames,
+			Issuer:   cert.Issuer.String(),
+			NotAfter: cert.NotAfter,
+		})
+	}
+	return chain
+}
+
+// evaluateCertificates runs the configured certificate assertions against the leaf certificate.
+func (test Application) evaluateCertificates(state *tls.ConnectionState, now time.Time) []CertResult {
+	if !test.hasCertAssertions() {
+		return nil
+	}
+	host := test.hostname()
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return []CertResult{{Kind: certNoTLS, Host: host}}
+	}
+	leaf := state.PeerCertificates[0]
+
+	var results []CertResult
+	if test.MinCertDaysRemaining > 0 {
+		days := int(leaf.NotAfter.Sub(now).Hours() / 24)
+		results = append(results, CertResult{
+			Kind:     certExpiry,
+			Host:     host,
+			Ok:       days >= test.MinCertDaysRemaining,
+			Expected: fmt.Sprint(test.MinCertDaysRemaining),
+			Actual:   fmt.Sprintf("%d days on %s", days, leaf.NotAfter.UTC().Format(time.DateOnly)),
+		})
+	}
+	if test.ExpectedCertIssuer != "" {
+		issuer := leaf.Issuer.String()
+		results = append(results, CertResult{
+			Kind:     certIssuer,
+			Host:     host,
+			Ok:       strings.Contains(strings.ToLower(issuer), strings.ToLower(test.ExpectedCertIssuer)),
+			Expected: test.ExpectedCertIssuer,
+			Actual:   issuer,
+		})
+	}
+	for _, hostname := range test.ExpectedCertHostnames {
+		results = append(results, CertResult{
+			Kind:     certHostname,
+			Host:     host,
+			Ok:       leaf.VerifyHostname(hostname) == nil,
+			Expected: hostname,
+			Actual:   strings.Join(leaf.DNSNames, ", "),
+		})
+	}
+	return results
+}
+
+// certErrorResults reports a request error caused by certificate verification as a certificate
+// failure, so that it is not mistaken for the site being down.
+func certErrorResults(test Application, err error) []CertResult {
+	if !isCertificateError(err) {
+		return nil
+	}
+	var verificationErr *tls.CertificateVerificationError
+	message := err.Error()
+	if errors.As(err, &verificationErr) {
+		message = verificationErr.Err.Error()
+	}
+	return []CertResult{{Kind: certInvalid, Host: test.hostname(), Actual: message}}
+}
+
+func isCertificateError(err error) bool {
+	var verificationErr *tls.CertificateVerificationError
+	var invalidErr x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	var authorityErr x509.UnknownAuthorityError
+	return errors.As(err, &verificationErr) || errors.As(err, &invalidErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &authorityErr)
+}
+
+func urlHostname(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return rawURL
+	}
+	return parsed.Hostname()
+}
+
+func (result CertResult) passed() bool {
+	return result.Ok
+}
+
+// certResultString formats one certificate assertion outcome.
+func certResultString(result CertResult) string {
+	switch result.Kind {
+	case certExpiry:
+		if result.Ok {
+			return fmt.Sprintf("Success: Certificate for %s expires in %s", result.Host, result.Actual)
+		}
+		return fmt.Sprintf("Failure: Certificate for %s expires in %s, less than min_cert_days_remaining %s", result.Host, result.Actual, result.Expected)
+	case certIssuer:
+		if result.Ok {
+			return fmt.Sprintf("Success: Certificate for %s issued by %s", result.Host, result.Actual)
+		}
+		return fmt.Sprintf("Failure: Certificate for %s issued by %s, expected %s", result.Host, result.Actual, result.Expected)
+	case certHostname:
+		if result.Ok {
+			return fmt.Sprintf("Success: Certificate for %s covers %s", result.Host, result.Expected)
+		}
+		return fmt.Sprintf("Failure: Certificate for %s does not cover %s (SANs: %s)", result.Host, result.Expected, result.Actual)
+	case certInvalid:
+		return fmt.Sprintf("Failure: Certificate for %s is not valid: %s", result.Host, result.Actual)
+	default:
+		return fmt.Sprintf("Failure: Certificate for %s could not be checked, the URL did not use TLS", result.Host)
+	}
+}
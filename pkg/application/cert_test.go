@@ -0,0 +1,178 @@
+package application
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func testConnectionState(notAfter time.Time) *tls.ConnectionState {
+	return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+		{
+			Subject:  pkix.Name{CommonName: "library.nyu.edu"},
+			Issuer:   pkix.Name{CommonName: "InCommon RSA Server CA 2", Organization: []string{"Internet2"}},
+			DNSNames: []string{"library.nyu.edu", "*.library.nyu.edu"},
+			NotAfter: notAfter,
+		},
+		{
+			Subject:  pkix.Name{CommonName: "InCommon RSA Server CA 2", Organization: []string{"Internet2"}},
+			Issuer:   pkix.Name{CommonName: "USERTrust RSA Certification Authority"},
+			NotAfter: notAfter.AddDate(5, 0, 0),
+		},
+	}}
+}
+
+func TestCertificateAssertionsUnmarshalYAML(t *testing.T) {
+	const config = `
+min_cert_days_remaining: 14
+expected_cert_issuer: InCommon
+expected_cert_hostnames:
+  - library.nyu.edu
+  - www.library.nyu.edu
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+
+	assert.Equal(t, 14, app.MinCertDaysRemaining)
+	assert.Equal(t, "InCommon", app.ExpectedCertIssuer)
+	assert.Equal(t, []string{"library.nyu.edu", "www.library.nyu.edu"}, app.ExpectedCertHostnames)
+	assert.True(t, app.hasCertAssertions())
+}
+
+func TestCertificateChain(t *testing.T) {
+	notAfter := time.Date(2026, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	chain := certificateChain(testConnectionState(notAfter))
+
+	require.Len(t, chain, 2)
+	assert.Equal(t, CertificateInfo{
+		Subject:  "CN=library.nyu.edu",
+		SANs:     []string{"library.nyu.edu", "*.library.nyu.edu"},
+		Issuer:   "CN=InCommon RSA Server CA 2,O=Internet2",
+		NotAfter: notAfter,
+	}, chain[0])
+	assert.Equal(t, "CN=USERTrust RSA Certification Authority", chain[1].Issuer)
+	assert.Nil(t, certificateChain(nil), "plain HTTP has no chain")
+}
+
+func TestEvaluateCertificates(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	state := testConnectionState(now.AddDate(0, 0, 45))
+
+	var tests = []struct {
+		description string
+		app         Application
+		state       *tls.ConnectionState
+		want        []CertResult
+	}{
+		{
+			"No assertions",
+			Application{URL: "https://library.nyu.edu/"},
+			state,
+			nil,
+		},
+		{
+			"Enough days remaining",
+			Application{URL: "https://library.nyu.edu/", MinCertDaysRemaining: 30},
+			state,
+			[]CertResult{{Kind: certExpiry, Host: "library.nyu.edu", Ok: true, Expected: "30", Actual: "45 days on 2026-12-01"}},
+		},
+		{
+			"Too few days remaining",
+			Application{URL: "https://library.nyu.edu/", MinCertDaysRemaining: 60},
+			state,
+			[]CertResult{{Kind: certExpiry, Host: "library.nyu.edu", Expected: "60", Actual: "45 days on 2026-12-01"}},
+		},
+		{
+			"Issuer matches case-insensitively",
+			Application{URL: "https://library.nyu.edu/", ExpectedCertIssuer: "incommon rsa"},
+			state,
+			[]CertResult{{Kind: certIssuer, Host: "library.nyu.edu", Ok: true, Expected: "incommon rsa", Actual: "CN=InCommon RSA Server CA 2,O=Internet2"}},
+		},
+		{
+			"Unexpected issuer",
+			Application{URL: "https://library.nyu.edu/", ExpectedCertIssuer: "Let's Encrypt"},
+			state,
+			[]CertResult{{Kind: certIssuer, Host: "library.nyu.edu", Expected: "Let's Encrypt", Actual: "CN=InCommon RSA Server CA 2,O=Internet2"}},
+		},
+		{
+			"Hostname coverage including wildcard",
+			Application{URL: "https://library.nyu.edu/", ExpectedCertHostnames: []string{"library.nyu.edu", "search.library.nyu.edu", "nyu.edu"}},
+			state,
+			[]CertResult{
+				{Kind: certHostname, Host: "library.nyu.edu", Ok: true, Expected: "library.nyu.edu", Actual: "library.nyu.edu, *.library.nyu.edu"},
+				{Kind: certHostname, Host: "library.nyu.edu", Ok: true, Expected: "search.library.nyu.edu", Actual: "library.nyu.edu, *.library.nyu.edu"},
+				{Kind: certHostname, Host: "library.nyu.edu", Expected: "nyu.edu", Actual: "library.nyu.edu, *.library.nyu.edu"},
+			},
+		},
+		{
+			"Plain HTTP",
+			Application{URL: "http://library.nyu.edu/", MinCertDaysRemaining: 14},
+			nil,
+			[]CertResult{{Kind: certNoTLS, Host: "library.nyu.edu"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.want, test.app.evaluateCertificates(test.state, now))
+		})
+	}
+}
+
+func TestCertResultString(t *testing.T) {
+	var tests = []struct {
+		result CertResult
+		want   string
+	}{
+		{CertResult{Kind: certExpiry, Host: "library.nyu.edu", Ok: true, Expected: "30", Actual: "45 days on 2026-12-01"}, "Success: Certificate for library.nyu.edu expires in 45 days on 2026-12-01"},
+		{CertResult{Kind: certExpiry, Host: "library.nyu.edu", Expected: "60", Actual: "45 days on 2026-12-01"}, "Failure: Certificate for library.nyu.edu expires in 45 days on 2026-12-01, less than min_cert_days_remaining 60"},
+		{CertResult{Kind: certIssuer, Host: "library.nyu.edu", Ok: true, Actual: "CN=R11,O=Let's Encrypt"}, "Success: Certificate for library.nyu.edu issued by CN=R11,O=Let's Encrypt"},
+		{CertResult{Kind: certIssuer, Host: "library.nyu.edu", Expected: "InCommon", Actual: "CN=R11,O=Let's Encrypt"}, "Failure: Certificate for library.nyu.edu issued by CN=R11,O=Let's Encrypt, expected InCommon"},
+		{CertResult{Kind: certHostname, Host: "library.nyu.edu", Ok: true, Expected: "www.library.nyu.edu"}, "Success: Certificate for library.nyu.edu covers www.library.nyu.edu"},
+		{CertResult{Kind: certHostname, Host: "library.nyu.edu", Expected: "nyu.edu", Actual: "library.nyu.edu"}, "Failure: Certificate for library.nyu.edu does not cover nyu.edu (SANs: library.nyu.edu)"},
+		{CertResult{Kind: certInvalid, Host: "library.nyu.edu", Actual: "x509: certificate has expired or is not yet valid"}, "Failure: Certificate for library.nyu.edu is not valid: x509: certificate has expired or is not yet valid"},
+		{CertResult{Kind: certNoTLS, Host: "library.nyu.edu"}, "Failure: Certificate for library.nyu.edu could not be checked, the URL did not use TLS"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			assert.Equal(t, test.want, certResultString(test.result))
+		})
+	}
+}
+
+func TestGetStatus_UntrustedCertificate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "untrusted",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.False(t, status.IsOk())
+	require.Len(t, status.CertResults, 1)
+	assert.Equal(t, certInvalid, status.CertResults[0].Kind)
+	assert.Contains(t, status.CertResults[0].Actual, "certificate signed by unknown authority")
+	assert.Contains(t, status.String(), "Failure: Certificate for 127.0.0.1 is not valid: x509: certificate signed by unknown authority")
+}
+
+func TestCertErrorResults_OtherErrors(t *testing.T) {
+	assert.Nil(t, certErrorResults(Application{URL: "https://library.nyu.edu/"}, assert.AnError))
+}
@@ -0,0 +1,58 @@
+package application
+
+import "net"
+
+// Check types for type. An empty type is an HTTP check.
+const (
+	CheckHTTP = "http"
+	CheckDNS  = "dns"
+	CheckTCP  = "tcp"
+)
+
+// isDNS reports whether the application is a DNS check rather than an HTTP one.
+func (test Application) isDNS() bool {
+	return test.Type == CheckDNS
+}
+
+// isTCP reports whether the application is a raw TCP check rather than an HTTP one.
+func (test Application) isTCP() bool {
+	return test.Type == CheckTCP
+}
+
+// Target returns what the check connects to: the URL, the name a DNS check resolves,
+// the host:port of a TCP check, or the first URL of a multi-step check.
+func (test Application) Target() string {
+	switch {
+	case test.isDNS() && test.DNS != nil:
+		return test.DNS.Name
+	case test.isTCP() && test.TCP != nil:
+		return test.TCP.Address
+	case test.URL == "" && test.hasSteps():
+		return test.Steps[0].URL
+	default:
+		return test.URL
+	}
+}
+
+// label names the check's target in output, e.g. "URL https://library.nyu.edu/".
+func (test Application) label() string {
+	switch {
+	case test.isDNS():
+		return "DNS " + test.Target()
+	case test.isTCP():
+		return "TCP " + test.Target()
+	default:
+		return "URL " + test.Target()
+	}
+}
+
+// hostname returns the host name the check connects to, used for certificate and TLS output.
+func (test Application) hostname() string {
+	if test.isTCP() {
+		if host, _, err := net.SplitHostPort(test.Target()); err == nil {
+			return host
+		}
+		return test.Target()
+	}
+	return urlHostname(test.URL)
+}
@@ -0,0 +1,201 @@
+package application
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported values for ContentAssertion.Mode
+const (
+	ContentLiteral         = "literal"
+	ContentRegex           = "regex"
+	ContentCaseInsensitive = "case_insensitive"
+	ContentMustNotContain  = "must_not_contain"
+)
+
+// ContentAssertion is a single expectation on the body of the final landing page.
+// The zero Mode is a literal substring match, the original expected_content behavior.
+type ContentAssertion struct {
+	Mode  string `yaml:"mode"`
+	Value string `yaml:"value"`
+}
+
+// ContentAssertions is the expected_content setting. In YAML it is either a single
+// string (a literal match) or a list whose items are strings or {mode, value} mappings.
+type ContentAssertions []ContentAssertion
+
+// ContentResult is the outcome of one content assertion.
+type ContentResult struct {
+	Assertion ContentAssertion
+	Ok        bool
+	Actual    string // the matched text, or the unexpected text found for must_not_contain
+	Err       string // set when the assertion itself is invalid, e.g. a bad regex
+}
+
+func (result ContentResult) passed() bool {
+	return result.Ok
+}
+
+// UnmarshalYAML accepts both the scalar and the list form of expected_content.
+func (assertions *ContentAssertions) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		var literal string
+		if err := value.Decode(&literal); err != nil {
+			return err
+		}
+		*assertions = nil
+		if literal != "" {
+			*assertions = ContentAssertions{{Value: literal}}
+		}
+		return nil
+	case yaml.SequenceNode:
+		list := make(ContentAssertions, 0, len(value.Content))
+		for _, item := range value.Content {
+			var assertion ContentAssertion
+			if item.Kind == yaml.ScalarNode {
+				if err := item.Decode(&assertion.Value); err != nil {
+					return err
+				}
+			} else if err := item.Decode(&assertion); err != nil {
+				return err
+			}
+			list = append(list, assertion)
+		}
+		*assertions = list
+		return nil
+	default:
+		return fmt.Errorf("line %d: expected_content must be a string or a list", value.Line)
+	}
+}
+
+// String lists the asserted values, so a single literal prints exactly as the plain string did.
+func (assertions ContentAssertions) String() string {
+	var values []string
+	for _, assertion := range assertions {
+		values = append(values, assertion.String())
+	}
+	return strings.Join(values, ", ")
+}
+
+func (assertion ContentAssertion) mode() string {
+	if assertion.Mode == "" {
+		return ContentLiteral
+	}
+	return assertion.Mode
+}
+
+// String describes the assertion as it appears in check output.
+func (assertion ContentAssertion) String() string {
+	switch assertion.mode() {
+	case ContentRegex:
+		return "matching /" + assertion.Value + "/"
+	case ContentCaseInsensitive:
+		return assertion.Value + " (case-insensitive)"
+	default:
+		return assertion.Value
+	}
+}
+
+// evaluate checks the assertion against the page body.
+func (assertion ContentAssertion) evaluate(body string) ContentResult {
+	result := ContentResult{Assertion: assertion}
+
+	switch assertion.mode() {
+	case ContentLiteral:
+		result.Ok, result.Actual = compareContent(body, assertion.Value)
+		if !result.Ok {
+			result.Actual = ""
+		}
+	case ContentRegex:
+		re, err := regexp.Compile(assertion.Value)
+		if err != nil {
+			result.Err = fmt.Sprintf("invalid regex: %v", err)
+			return result
+		}
+		if loc := re.FindStringIndex(body); loc != nil {
+			result.Ok, result.Actual = true, body[loc[0]:loc[1]]
+		}
+	case ContentCaseInsensitive:
+		re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(assertion.Value))
+		if loc := re.FindStringIndex(body); loc != nil {
+			result.Ok, result.Actual = true, body[loc[0]:loc[1]]
+		}
+	case ContentMustNotContain:
+		result.Ok = !strings.Contains(body, assertion.Value)
+		if !result.Ok {
+			result.Actual = assertion.Value
+		}
+	default:
+		result.Err = fmt.Sprintf("unsupported content mode %q", assertion.Mode)
+	}
+	return result
+}
+
+// evaluateContent runs every content assertion against the page body.
+// actualContent is the first assertion's matched text when all pass, and the whole body otherwise,
+// which for a single literal is the original expected_content behavior.
+func evaluateContent(body string, assertions ContentAssertions) (ok bool, actualContent string, results []ContentResult) {
+	ok = true
+	for _, assertion := range assertions {
+		result := assertion.evaluate(body)
+		ok = ok && result.Ok
+		results = append(results, result)
+	}
+
+	if !ok {
+		return false, body, results
+	}
+	if len(results) > 0 {
+		actualContent = results[0].Actual
+	}
+	return true, actualContent, results
+}
+
+// contentResultString formats one content assertion outcome. The page body is only included
+// with the first failure (showBody), so several failing assertions do not repeat it.
+func contentResultString(results AppCheckStatus, result ContentResult, showBody bool) string {
+	assertion := result.Assertion
+
+	if assertion.mode() == ContentMustNotContain {
+		if result.Ok {
+			return fmt.Sprintf("Success: Unexpected content %s was not found", assertion.Value)
+		}
+		return fmt.Sprintf("Failure: Unexpected content %s was found", assertion.Value)
+	}
+
+	if result.Ok {
+		return fmt.Sprintf("Success: ExpectedContent %s matched ActualContent %s", assertion, result.Actual)
+	}
+	if result.Err != "" {
+		return fmt.Sprintf("Failure: Expected content %s could not be evaluated: %s", assertion, result.Err)
+	}
+	if showBody && results.ActualContent != "" && (results.Application.IncludeActualContentOnFailure || (IsPrimoVE && DebugMode)) {
+		return fmt.Sprintf("Failure: Expected content %s did not match Actual Content %s", assertion, results.ActualContent)
+	}
+	return fmt.Sprintf("Failure: Expected content %s did not match Actual Content", assertion)
+}
+
+// contentResultsStrings formats each content assertion outcome on its own line.
+func contentResultsStrings(results AppCheckStatus) []string {
+	contentOk := true
+	for _, result := range results.ContentResults {
+		contentOk = contentOk && result.Ok
+	}
+	if results.ActualContent == "" && !contentOk {
+		return []string{"Failure: No content to compare"}
+	}
+
+	var output []string
+	bodyShown := false
+	for _, result := range results.ContentResults {
+		output = append(output, contentResultString(results, result, !bodyShown))
+		if !result.Ok {
+			bodyShown = true
+		}
+	}
+	return output
+}
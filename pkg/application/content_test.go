@@ -0,0 +1,201 @@
+package application
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestContentAssertionsUnmarshalYAML(t *testing.T) {
+	var tests = []struct {
+		description string
+		yaml        string
+		want        ContentAssertions
+		wantErr     string
+	}{
+		{"Scalar is a single literal", `expected_content: 'Research Guides'`, ContentAssertions{{Value: "Research Guides"}}, ""},
+		{"Empty scalar means no assertion", `expected_content: ''`, nil, ""},
+		{"List of scalars", "expected_content:\n  - FAQs\n  - Ask Us", ContentAssertions{{Value: "FAQs"}, {Value: "Ask Us"}}, ""},
+		{
+			"List of modes",
+			"expected_content:\n  - mode: regex\n    value: 'lib/bundle\\.js\\?version=[0-9a-f]+'\n  - mode: must_not_contain\n    value: 'Service Unavailable'",
+			ContentAssertions{{Mode: ContentRegex, Value: `lib/bundle\.js\?version=[0-9a-f]+`}, {Mode: ContentMustNotContain, Value: "Service Unavailable"}},
+			"",
+		},
+		{"Mapping is rejected", "expected_content:\n  value: FAQs", nil, "expected_content must be a string or a list"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var app Application
+			err := yaml.Unmarshal([]byte(test.yaml), &app)
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, app.ExpectedContent)
+		})
+	}
+}
+
+func TestContentAssertionEvaluate(t *testing.T) {
+	const body = `<html><script src="lib/bundle.js?version=af6f613389"></script><h1>Research Guides</h1></html>`
+
+	var tests = []struct {
+		description string
+		assertion   ContentAssertion
+		wantOk      bool
+		wantActual  string
+		wantErr     string
+	}{
+		{"Literal found", ContentAssertion{Value: "Research Guides"}, true, "Research Guides", ""},
+		{"Literal not found", ContentAssertion{Mode: ContentLiteral, Value: "research guides"}, false, "", ""},
+		{"Regex found", ContentAssertion{Mode: ContentRegex, Value: `lib/bundle\.js\?version=[0-9a-f]+`}, true, "lib/bundle.js?version=af6f613389", ""},
+		{"Regex not found", ContentAssertion{Mode: ContentRegex, Value: `main\.[0-9a-f]+\.js`}, false, "", ""},
+		{"Invalid regex", ContentAssertion{Mode: ContentRegex, Value: `bundle(`}, false, "", "invalid regex"},
+		{"Case-insensitive found", ContentAssertion{Mode: ContentCaseInsensitive, Value: "research guides"}, true, "Research Guides", ""},
+		{"Case-insensitive escapes regex metacharacters", ContentAssertion{Mode: ContentCaseInsensitive, Value: "BUNDLE.JS?VERSION="}, true, "bundle.js?version=", ""},
+		{"Must not contain absent", ContentAssertion{Mode: ContentMustNotContain, Value: "Service Unavailable"}, true, "", ""},
+		{"Must not contain present", ContentAssertion{Mode: ContentMustNotContain, Value: "Research"}, false, "Research", ""},
+		{"Unsupported mode", ContentAssertion{Mode: "fuzzy", Value: "Research"}, false, "", `unsupported content mode "fuzzy"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			result := test.assertion.evaluate(body)
+			assert.Equal(t, test.wantOk, result.Ok)
+			assert.Equal(t, test.wantActual, result.Actual)
+			if test.wantErr != "" {
+				assert.Contains(t, result.Err, test.wantErr)
+			} else {
+				assert.Empty(t, result.Err)
+			}
+		})
+	}
+}
+
+func TestEvaluateContent(t *testing.T) {
+	const body = "NYU Libraries Classes, Workshops, and Events"
+
+	ok, actual, results := evaluateContent(body, ContentAssertions{{Value: "Workshops"}, {Mode: ContentCaseInsensitive, Value: "EVENTS"}})
+	assert.True(t, ok)
+	assert.Equal(t, "Workshops", actual, "on success the first assertion's match is kept")
+	assert.Len(t, results, 2)
+
+	ok, actual, results = evaluateContent(body, ContentAssertions{{Value: "Workshops"}, {Mode: ContentMustNotContain, Value: "Classes"}})
+	assert.False(t, ok)
+	assert.Equal(t, body, actual, "on failure the whole body is kept")
+	assert.True(t, results[0].Ok)
+	assert.False(t, results[1].Ok)
+}
+
+func TestStringWithContentResults(t *testing.T) {
+	originalIsPrimoVE := IsPrimoVE
+	originalDebugMode := DebugMode
+	defer func() {
+		IsPrimoVE = originalIsPrimoVE
+		DebugMode = originalDebugMode
+	}()
+	IsPrimoVE, DebugMode = false, false
+
+	regex := ContentAssertion{Mode: ContentRegex, Value: `bundle\.js\?version=[0-9a-f]+`}
+	mustNot := ContentAssertion{Mode: ContentMustNotContain, Value: "Service Unavailable"}
+	caseless := ContentAssertion{Mode: ContentCaseInsensitive, Value: "primo"}
+
+	var tests = []struct {
+		description    string
+		appStatus      *AppCheckStatus
+		expectedOutput string
+	}{
+		{
+			description: "Single literal keeps the original format",
+			appStatus: &AppCheckStatus{
+				Application: &Application{URL: "https://guides.nyu.edu/", ExpectedContent: ContentAssertions{{Value: "Research Guides"}}},
+				StatusOk:    true, StatusContentOk: true, ActualStatusCode: 200, ActualContent: "Research Guides",
+				ContentResults: []ContentResult{{Assertion: ContentAssertion{Value: "Research Guides"}, Ok: true, Actual: "Research Guides"}},
+			},
+			expectedOutput: "Success: URL https://guides.nyu.edu/ resolved with 200\nSuccess: ExpectedContent Research Guides matched ActualContent Research Guides",
+		},
+		{
+			description: "Each assertion is reported individually",
+			appStatus: &AppCheckStatus{
+				Application: &Application{URL: "https://search.library.nyu.edu/", ExpectedContent: ContentAssertions{regex, mustNot, caseless}},
+				StatusOk:    true, StatusContentOk: true, ActualStatusCode: 200, ActualContent: "bundle.js?version=af6f613389",
+				ContentResults: []ContentResult{
+					{Assertion: regex, Ok: true, Actual: "bundle.js?version=af6f613389"},
+					{Assertion: mustNot, Ok: true},
+					{Assertion: caseless, Ok: true, Actual: "Primo"},
+				},
+			},
+			expectedOutput: "Success: URL https://search.library.nyu.edu/ resolved with 200\n" +
+				"Success: ExpectedContent matching /bundle\\.js\\?version=[0-9a-f]+/ matched ActualContent bundle.js?version=af6f613389\n" +
+				"Success: Unexpected content Service Unavailable was not found\n" +
+				"Success: ExpectedContent primo (case-insensitive) matched ActualContent Primo",
+		},
+		{
+			description: "Body is shown once with the first failure",
+			appStatus: &AppCheckStatus{
+				Application: &Application{URL: "https://search.library.nyu.edu/", IncludeActualContentOnFailure: true, ExpectedContent: ContentAssertions{regex, mustNot, caseless}},
+				StatusOk:    true, StatusContentOk: false, ActualStatusCode: 200, ActualContent: "Service Unavailable",
+				ContentResults: []ContentResult{
+					{Assertion: regex},
+					{Assertion: mustNot, Actual: "Service Unavailable"},
+					{Assertion: caseless},
+				},
+			},
+			expectedOutput: "Success: URL https://search.library.nyu.edu/ resolved with 200\n" +
+				"Failure: Expected content matching /bundle\\.js\\?version=[0-9a-f]+/ did not match Actual Content Service Unavailable\n" +
+				"Failure: Unexpected content Service Unavailable was found\n" +
+				"Failure: Expected content primo (case-insensitive) did not match Actual Content",
+		},
+		{
+			description: "Invalid assertion is called out",
+			appStatus: &AppCheckStatus{
+				Application: &Application{URL: "https://search.library.nyu.edu/", ExpectedContent: ContentAssertions{{Mode: ContentRegex, Value: "("}}},
+				StatusOk:    true, StatusContentOk: false, ActualStatusCode: 200, ActualContent: "<html></html>",
+				ContentResults: []ContentResult{{Assertion: ContentAssertion{Mode: ContentRegex, Value: "("}, Err: "invalid regex: missing closing )"}},
+			},
+			expectedOutput: "Success: URL https://search.library.nyu.edu/ resolved with 200\n" +
+				"Failure: Expected content matching /(/ could not be evaluated: invalid regex: missing closing )",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.expectedOutput, test.appStatus.String())
+		})
+	}
+}
+
+func TestGetStatus_ContentAssertions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><script src="lib/bundle.js?version=cb1d5eb933"></script></html>`)
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "primo-ve-search-html",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		ExpectedContent: ContentAssertions{
+			{Mode: ContentRegex, Value: `src="lib/bundle\.js\?version=[0-9a-f]+"`},
+			{Mode: ContentMustNotContain, Value: "Service Unavailable"},
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusContentOk, "a new bundle version should still match the regex")
+	require.Len(t, status.ContentResults, 2)
+	assert.Equal(t, `src="lib/bundle.js?version=cb1d5eb933"`, status.ContentResults[0].Actual)
+	assert.True(t, status.ContentResults[1].Ok)
+}
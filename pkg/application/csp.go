@@ -0,0 +1,167 @@
+package application
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CSP comparison modes for csp_mode.
+const (
+	// CSPExact requires the same directives with the same sources, in any order.
+	CSPExact = "exact"
+	// CSPIncludes requires at least the expected directives and sources; extras are allowed.
+	CSPIncludes = "includes"
+	// CSPExcludes requires none of the listed sources; a listed directive without sources must be absent.
+	CSPExcludes = "excludes"
+)
+
+// cspPolicy is a parsed Content-Security-Policy: directive names in header order and
+// the sources of each.
+type cspPolicy struct {
+	directives []string
+	sources    map[string][]string
+}
+
+// CSPDifference describes how one directive of the actual policy differs from the expected one.
+type CSPDifference struct {
+	Directive  string
+	Missing    bool     // the directive itself is missing
+	Unexpected bool     // the directive itself should not be there
+	Sources    []string // sources missing from the directive
+	Extra      []string // sources the directive should not have
+}
+
+// parseCSP splits a policy into directives and sources. Directive names and source keywords
+// are case-insensitive, so they are lowercased; nonces and hashes keep their case. As in
+// browsers, only the first occurrence of a repeated directive counts.
+func parseCSP(header string) cspPolicy {
+	policy := cspPolicy{sources: map[string][]string{}}
+	for _, directive := range strings.Split(header, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, seen := policy.sources[name]; seen {
+			continue
+		}
+		sources := make([]string, 0, len(fields)-1)
+		for _, source := range fields[1:] {
+			source = normalizeCSPSource(source)
+			if !slices.Contains(sources, source) {
+				sources = append(sources, source)
+			}
+		}
+		policy.directives = append(policy.directives, name)
+		policy.sources[name] = sources
+	}
+	return policy
+}
+
+func normalizeCSPSource(source string) string {
+	lower := strings.ToLower(source)
+	if strings.HasPrefix(lower, "'nonce-") || strings.HasPrefix(lower, "'sha") {
+		return source
+	}
+	return lower
+}
+
+// compareCSP compares the actual and expected CSP directive by directive according to mode.
+// It returns whether they match and, when they do not, only the directives and sources that differ.
+func compareCSP(actual, expected, mode string) (bool, []CSPDifference) {
+	if expected == "" {
+		return true, nil // No CSP check required
+	}
+	actualPolicy, expectedPolicy := parseCSP(actual), parseCSP(expected)
+
+	var differences []CSPDifference
+	switch mode {
+	case CSPExcludes:
+		for _, name := range expectedPolicy.directives {
+			actualSources, present := actualPolicy.sources[name]
+			forbidden := expectedPolicy.sources[name]
+			if len(forbidden) == 0 {
+				if present {
+					differences = append(differences, CSPDifference{Directive: name, Unexpected: true})
+				}
+				continue
+			}
+			if extra := intersectSources(actualSources, forbidden); len(extra) > 0 {
+				differences = append(differences, CSPDifference{Directive: name, Extra: extra})
+			}
+		}
+	default:
+		for _, name := range expectedPolicy.directives {
+			actualSources, present := actualPolicy.sources[name]
+			if !present {
+				differences = append(differences, CSPDifference{Directive: name, Missing: true})
+				continue
+			}
+			difference := CSPDifference{Directive: name, Sources: subtractSources(expectedPolicy.sources[name], actualSources)}
+			if mode != CSPIncludes {
+				difference.Extra = subtractSources(actualSources, expectedPolicy.sources[name])
+			}
+			if len(difference.Sources) > 0 || len(difference.Extra) > 0 {
+				differences = append(differences, difference)
+			}
+		}
+		if mode != CSPIncludes {
+			for _, name := range actualPolicy.directives {
+				if _, expected := expectedPolicy.sources[name]; !expected {
+					differences = append(differences, CSPDifference{Directive: name, Unexpected: true})
+				}
+			}
+		}
+	}
+	return len(differences) == 0, differences
+}
+
+// subtractSources returns the sources in a that are not in b, in a's order.
+func subtractSources(a, b []string) []string {
+	var out []string
+	for _, source := range a {
+		if !slices.Contains(b, source) {
+			out = append(out, source)
+		}
+	}
+	return out
+}
+
+// intersectSources returns the sources in a that are also in b, in a's order.
+func intersectSources(a, b []string) []string {
+	var out []string
+	for _, source := range a {
+		if slices.Contains(b, source) {
+			out = append(out, source)
+		}
+	}
+	return out
+}
+
+// String describes the difference, e.g. "script-src missing 'self', unexpected 'unsafe-eval'".
+func (difference CSPDifference) String() string {
+	switch {
+	case difference.Missing:
+		return "missing directive " + difference.Directive
+	case difference.Unexpected:
+		return "unexpected directive " + difference.Directive
+	}
+	var parts []string
+	if len(difference.Sources) > 0 {
+		parts = append(parts, "missing "+strings.Join(difference.Sources, " "))
+	}
+	if len(difference.Extra) > 0 {
+		parts = append(parts, "unexpected "+strings.Join(difference.Extra, " "))
+	}
+	return fmt.Sprintf("%s %s", difference.Directive, strings.Join(parts, ", "))
+}
+
+// cspDifferencesString joins the differences for a single failure line.
+func cspDifferencesString(differences []CSPDifference) string {
+	parts := make([]string, 0, len(differences))
+	for _, difference := range differences {
+		parts = append(parts, difference.String())
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,78 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSP(t *testing.T) {
+	policy := parseCSP("object-src blob: 'SELF' *.exlibrisgroup.com ;Worker-Src blob: 'self' blob: ;upgrade-insecure-requests; script-src 'nonce-AbC123'; object-src 'none';")
+
+	assert.Equal(t, []string{"object-src", "worker-src", "upgrade-insecure-requests", "script-src"}, policy.directives)
+	assert.Equal(t, []string{"blob:", "'self'", "*.exlibrisgroup.com"}, policy.sources["object-src"], "the first occurrence of a directive wins")
+	assert.Equal(t, []string{"blob:", "'self'"}, policy.sources["worker-src"], "repeated sources are dropped")
+	assert.Empty(t, policy.sources["upgrade-insecure-requests"])
+	assert.Equal(t, []string{"'nonce-AbC123'"}, policy.sources["script-src"], "nonces keep their case")
+}
+
+func TestCompareCSP(t *testing.T) {
+	const actual = "default-src 'self'; script-src 'self' 'unsafe-eval' *.exlibrisgroup.com; upgrade-insecure-requests"
+
+	var tests = []struct {
+		description string
+		expected    string
+		mode        string
+		wantOk      bool
+		wantDiff    string
+	}{
+		{"No expectation", "", "", true, ""},
+		{"Same policy in another order", "upgrade-insecure-requests;script-src *.exlibrisgroup.com 'unsafe-eval' 'self' ;default-src 'self';", CSPExact, true, ""},
+		{"Default mode is exact", "default-src 'self'; script-src 'self'; upgrade-insecure-requests", "", false, "script-src unexpected 'unsafe-eval' *.exlibrisgroup.com"},
+		{"Missing and unexpected sources", "default-src 'self'; script-src 'self' 'strict-dynamic' *.exlibrisgroup.com; upgrade-insecure-requests", CSPExact, false, "script-src missing 'strict-dynamic', unexpected 'unsafe-eval'"},
+		{"Missing and unexpected directives", "default-src 'self'; script-src 'self' 'unsafe-eval' *.exlibrisgroup.com; frame-ancestors 'none'", CSPExact, false, "missing directive frame-ancestors; unexpected directive upgrade-insecure-requests"},
+		{"Includes a subset", "script-src 'self'; upgrade-insecure-requests", CSPIncludes, true, ""},
+		{"Includes a missing source", "script-src 'self' 'strict-dynamic'", CSPIncludes, false, "script-src missing 'strict-dynamic'"},
+		{"Includes a missing directive", "frame-ancestors 'none'", CSPIncludes, false, "missing directive frame-ancestors"},
+		{"Excludes absent sources", "script-src 'unsafe-inline'; object-src 'unsafe-eval'; block-all-mixed-content", CSPExcludes, true, ""},
+		{"Excludes a present source", "script-src 'unsafe-inline' 'unsafe-eval'", CSPExcludes, false, "script-src unexpected 'unsafe-eval'"},
+		{"Excludes a present directive", "upgrade-insecure-requests", CSPExcludes, false, "unexpected directive upgrade-insecure-requests"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			ok, differences := compareCSP(actual, test.expected, test.mode)
+			assert.Equal(t, test.wantOk, ok)
+			assert.Equal(t, test.wantDiff, cspDifferencesString(differences))
+		})
+	}
+}
+
+func TestGetStatus_CSPDifferences(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "object-src blob: 'self' *.exlibrisgroup.com ;worker-src blob: 'self' ;report-uri /infra/CSPReportEndpoint.jsp;")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "primo-ve",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		ExpectedCSP:        "object-src blob: 'self' *.exlibrisgroup.com search.library.nyu.edu ;worker-src blob: 'self' ;upgrade-insecure-requests; report-uri /infra/CSPReportEndpoint.jsp;",
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.False(t, status.StatusCSPOk)
+	assert.False(t, status.IsOk())
+	assert.Equal(t, "Success: URL "+srv.URL+" resolved with 200\n"+
+		"Failure: Expected Primo VE CSP header did not match Actual CSP header: object-src missing search.library.nyu.edu; missing directive upgrade-insecure-requests",
+		outputWithoutTimings(status))
+}
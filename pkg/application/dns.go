@@ -0,0 +1,274 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DNSCheck configures a type: dns check: the name to resolve, the resolver to ask
+// ("host" or "host:port", the system resolver by default) and assertions on its records.
+// Without records the check only requires the name to resolve to an address.
+type DNSCheck struct {
+	Name     string               `yaml:"name"`
+	Resolver string               `yaml:"resolver"`
+	Records  []DNSRecordAssertion `yaml:"records"`
+}
+
+// DNSRecordAssertion is an expectation on the records of one type: A, AAAA, CNAME, TXT or MX.
+// equals is the exact set of records in any order, contains a subset of them, and regex a
+// Go regular expression every record must match. Host names are compared in lower case
+// without the trailing dot; MX records are compared by host name only.
+type DNSRecordAssertion struct {
+	Type     string   `yaml:"type"`
+	Equals   []string `yaml:"equals"`
+	Contains []string `yaml:"contains"`
+	Regex    string   `yaml:"regex"`
+}
+
+// DNSResult is the outcome of one record assertion.
+type DNSResult struct {
+	Assertion DNSRecordAssertion
+	Ok        bool
+	Actual    []string
+	Err       string
+}
+
+// String describes the assertion as it appears in check output.
+func (assertion DNSRecordAssertion) String() string {
+	var parts []string
+	if assertion.Equals != nil {
+		parts = append(parts, fmt.Sprintf("equals %v", assertion.Equals))
+	}
+	if len(assertion.Contains) > 0 {
+		parts = append(parts, fmt.Sprintf("contains %v", assertion.Contains))
+	}
+	if assertion.Regex != "" {
+		parts = append(parts, "matches /"+assertion.Regex+"/")
+	}
+	if len(parts) == 0 {
+		return "exists"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resolver returns the resolver to query: the system one, or the configured server.
+func (check DNSCheck) resolver() *net.Resolver {
+	if check.Resolver == "" {
+		return net.DefaultResolver
+	}
+	address := check.Resolver
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// getDNSStatus resolves the configured name and evaluates the record assertions; lookup
+// errors for a record type are reported on that assertion. The time spent on all lookups
+// is checked against max_response_time and warn_response_time.
+func (test Application) getDNSStatus() *AppCheckStatus {
+	status := &AppCheckStatus{Application: &test, StatusContentOk: true, StatusCSPOk: true}
+	if test.DNS == nil || test.DNS.Name == "" {
+		status.Error = "has no dns.name"
+		return status
+	}
+
+	ctx := context.Background()
+	if test.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, test.Timeout)
+		defer cancel()
+	}
+	resolver := test.DNS.resolver()
+	// A fully qualified name keeps resolv.conf search domains out of the lookup.
+	name := strings.TrimSuffix(test.DNS.Name, ".") + "."
+
+	start := time.Now()
+	var lookupErr error
+	if len(test.DNS.Records) == 0 {
+		var addrs []string
+		addrs, lookupErr = lookupRecords(ctx, resolver, "IP", name)
+		if lookupErr == nil && len(addrs) == 0 {
+			lookupErr = fmt.Errorf("no addresses for %s", test.DNS.Name)
+		}
+	}
+	for _, assertion := range test.DNS.Records {
+		status.DNSResults = append(status.DNSResults, assertion.evaluate(ctx, *test.DNS, resolver, name))
+	}
+	elapsed := time.Since(start)
+
+	if lookupErr != nil {
+		lookupErr = test.DNS.describeError(lookupErr)
+		log.Printf("[%s] DNS error: %v", test.Name, lookupErr)
+		status.Error = lookupErr.Error()
+	}
+	status.StatusOk = lookupErr == nil
+	status.Timings = Timings{DNS: elapsed, Total: elapsed}
+	status.ResponseTimeExceeded, status.ResponseTimeWarning = test.evaluateResponseTime(elapsed)
+	return status
+}
+
+// lookupRecords returns the records of the given type, normalized for comparison.
+// "IP" looks up both A and AAAA records.
+func lookupRecords(ctx context.Context, resolver *net.Resolver, recordType, name string) ([]string, error) {
+	var records []string
+	switch strings.ToUpper(recordType) {
+	case "IP", "A", "AAAA":
+		network := map[string]string{"IP": "ip", "A": "ip4", "AAAA": "ip6"}[strings.ToUpper(recordType)]
+		addrs, err := resolver.LookupNetIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			records = append(records, addr.Unmap().String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if cname = normalizeHost(cname); cname != normalizeHost(name) {
+			records = append(records, cname)
+		}
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records = txts
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, normalizeHost(mx.Host))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+	return records, nil
+}
+
+// describeError names the configured resolver in lookup errors; net.DNSError would
+// name the system resolver even though it was not asked.
+func (check DNSCheck) describeError(err error) error {
+	var dnsErr *net.DNSError
+	if check.Resolver == "" || !errors.As(err, &dnsErr) {
+		return err
+	}
+	return fmt.Errorf("lookup %s via %s: %s", strings.TrimSuffix(dnsErr.Name, "."), check.Resolver, dnsErr.Err)
+}
+
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func (assertion DNSRecordAssertion) evaluate(ctx context.Context, check DNSCheck, resolver *net.Resolver, name string) DNSResult {
+	result := DNSResult{Assertion: assertion}
+	records, err := lookupRecords(ctx, resolver, assertion.Type, name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			err = fmt.Errorf("no %s records", strings.ToUpper(assertion.Type))
+		}
+		result.Err = check.describeError(err).Error()
+		return result
+	}
+	result.Actual = records
+
+	normalize := func(value string) string { return value }
+	if t := strings.ToUpper(assertion.Type); t == "CNAME" || t == "MX" {
+		normalize = normalizeHost
+	}
+
+	result.Ok = len(records) > 0
+	if assertion.Equals != nil {
+		expected := make([]string, 0, len(assertion.Equals))
+		for _, value := range assertion.Equals {
+			expected = append(expected, normalize(value))
+		}
+		actual := slices.Clone(records)
+		slices.Sort(expected)
+		slices.Sort(actual)
+		result.Ok = slices.Equal(slices.Compact(expected), slices.Compact(actual))
+	}
+	for _, value := range assertion.Contains {
+		if !slices.Contains(records, normalize(value)) {
+			result.Ok = false
+		}
+	}
+	if assertion.Regex != "" {
+		re, err := regexp.Compile(assertion.Regex)
+		if err != nil {
+			result.Ok = false
+			result.Err = fmt.Sprintf("invalid regex: %v", err)
+			return result
+		}
+		for _, record := range records {
+			if !re.MatchString(record) {
+				result.Ok = false
+			}
+		}
+	}
+	return result
+}
+
+func (result DNSResult) passed() bool {
+	return result.Ok
+}
+
+// dnsResultsStrings reports the status and record assertions of a DNS check.
+func dnsResultsStrings(results AppCheckStatus) []string {
+	output := []string{dnsStatusString(results)}
+	for _, result := range results.DNSResults {
+		output = append(output, dnsResultString(results.Application.DNS.Name, result))
+	}
+	return output
+}
+
+// dnsStatusString reports how long the lookups took, or why the name did not resolve.
+func dnsStatusString(results AppCheckStatus) string {
+	app := results.Application
+	if app.DNS == nil {
+		return fmt.Sprintf("Failure: DNS check %s %s", app.Name, results.Error)
+	}
+	name := app.DNS.Name
+	elapsed := roundDuration(results.Timings.Total)
+	switch {
+	case results.Error != "":
+		return fmt.Sprintf("Failure: DNS %s did not resolve: %s", name, results.Error)
+	case results.ResponseTimeExceeded:
+		return fmt.Sprintf("Failure: DNS %s answered in %s, over max_response_time %s", name, elapsed, app.MaxResponseTime)
+	case results.ResponseTimeWarning:
+		return fmt.Sprintf("Slow: DNS %s answered in %s, over warn_response_time %s", name, elapsed, app.WarnResponseTime)
+	default:
+		return fmt.Sprintf("Success: DNS %s answered in %s", name, elapsed)
+	}
+}
+
+// dnsResultString formats one record assertion outcome, including the actual records on failure.
+func dnsResultString(name string, result DNSResult) string {
+	recordType := strings.ToUpper(result.Assertion.Type)
+	switch {
+	case result.Err != "":
+		return fmt.Sprintf("Failure: DNS %s %s %s could not be evaluated: %s", recordType, name, result.Assertion, result.Err)
+	case result.Ok:
+		return fmt.Sprintf("Success: DNS %s %s %s", recordType, name, result.Assertion)
+	default:
+		return fmt.Sprintf("Failure: DNS %s %s %s, actual: %v", recordType, name, result.Assertion, result.Actual)
+	}
+}
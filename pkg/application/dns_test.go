@@ -0,0 +1,213 @@
+package application
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+	"gopkg.in/yaml.v3"
+)
+
+// startDNSServer runs a stand-in DNS server on a local UDP port answering from records,
+// keyed by fully qualified name. Names it does not know get NXDOMAIN.
+func startDNSServer(t *testing.T, records map[string][]dnsmessage.Resource) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var parser dnsmessage.Parser
+			header, err := parser.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			question, err := parser.Question()
+			if err != nil {
+				continue
+			}
+
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true, RCode: dnsmessage.RCodeSuccess},
+				Questions: []dnsmessage.Question{question},
+			}
+			known, ok := records[strings.ToLower(question.Name.String())]
+			if !ok {
+				response.Header.RCode = dnsmessage.RCodeNameError
+			}
+			for _, record := range known {
+				if record.Header.Type == question.Type || record.Header.Type == dnsmessage.TypeCNAME {
+					response.Answers = append(response.Answers, record)
+				}
+			}
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func dnsRecord(name string, recordType dnsmessage.Type, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: recordType, Class: dnsmessage.ClassINET, TTL: 300},
+		Body:   body,
+	}
+}
+
+func testDNSServer(t *testing.T) string {
+	return startDNSServer(t, map[string][]dnsmessage.Resource{
+		"libguides.nyu.edu.": {
+			dnsRecord("libguides.nyu.edu.", dnsmessage.TypeCNAME, &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("nyu.libguides.com.")}),
+			dnsRecord("nyu.libguides.com.", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{54, 85, 10, 1}}),
+			dnsRecord("nyu.libguides.com.", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{54, 85, 10, 2}}),
+		},
+		"library.nyu.edu.": {
+			dnsRecord("library.nyu.edu.", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{128, 122, 1, 1}}),
+			dnsRecord("library.nyu.edu.", dnsmessage.TypeAAAA, &dnsmessage.AAAAResource{AAAA: [16]byte{0x26, 0x07, 0xf6, 0x00, 15: 1}}),
+			dnsRecord("library.nyu.edu.", dnsmessage.TypeTXT, &dnsmessage.TXTResource{TXT: []string{"v=spf1 include:_spf.google.com ~all"}}),
+			dnsRecord("library.nyu.edu.", dnsmessage.TypeMX, &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("ASPMX.L.GOOGLE.COM.")}),
+		},
+	})
+}
+
+func TestDNSCheckUnmarshalYAML(t *testing.T) {
+	const config = `
+name: libguides-dns
+type: dns
+dns:
+  name: libguides.nyu.edu
+  resolver: 8.8.8.8
+  records:
+    - type: CNAME
+      equals: ['nyu.libguides.com']
+    - type: A
+      regex: '^54\.'
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+
+	assert.True(t, app.isDNS())
+	require.NotNil(t, app.DNS)
+	assert.Equal(t, "libguides.nyu.edu", app.DNS.Name)
+	assert.Equal(t, "8.8.8.8", app.DNS.Resolver)
+	require.Len(t, app.DNS.Records, 2)
+	assert.Equal(t, []string{"nyu.libguides.com"}, app.DNS.Records[0].Equals)
+	assert.Equal(t, "libguides.nyu.edu", app.Target())
+}
+
+func TestDNSRecordAssertions(t *testing.T) {
+	check := DNSCheck{Resolver: testDNSServer(t)}
+	resolver := check.resolver()
+
+	var tests = []struct {
+		description string
+		name        string
+		assertion   DNSRecordAssertion
+		wantOk      bool
+		wantActual  []string
+		wantErr     string
+	}{
+		{"CNAME equals, case and trailing dot ignored", "libguides.nyu.edu.", DNSRecordAssertion{Type: "CNAME", Equals: []string{"NYU.LibGuides.com."}}, true, []string{"nyu.libguides.com"}, ""},
+		{"CNAME changed", "libguides.nyu.edu.", DNSRecordAssertion{Type: "CNAME", Equals: []string{"libguides.cdn.example.net"}}, false, []string{"nyu.libguides.com"}, ""},
+		{"No CNAME", "library.nyu.edu.", DNSRecordAssertion{Type: "CNAME", Equals: []string{}}, true, nil, ""},
+		{"A equals in any order", "libguides.nyu.edu.", DNSRecordAssertion{Type: "A", Equals: []string{"54.85.10.2", "54.85.10.1"}}, true, []string{"54.85.10.1", "54.85.10.2"}, ""},
+		{"A equals is an exact set", "libguides.nyu.edu.", DNSRecordAssertion{Type: "a", Equals: []string{"54.85.10.1"}}, false, []string{"54.85.10.1", "54.85.10.2"}, ""},
+		{"A contains", "libguides.nyu.edu.", DNSRecordAssertion{Type: "A", Contains: []string{"54.85.10.2"}}, true, []string{"54.85.10.1", "54.85.10.2"}, ""},
+		{"A regex must match every record", "libguides.nyu.edu.", DNSRecordAssertion{Type: "A", Regex: `\.1$`}, false, []string{"54.85.10.1", "54.85.10.2"}, ""},
+		{"AAAA exists", "library.nyu.edu.", DNSRecordAssertion{Type: "AAAA"}, true, []string{"2607:f600::1"}, ""},
+		{"TXT regex", "library.nyu.edu.", DNSRecordAssertion{Type: "TXT", Regex: `^v=spf1 `}, true, []string{"v=spf1 include:_spf.google.com ~all"}, ""},
+		{"MX by host name", "library.nyu.edu.", DNSRecordAssertion{Type: "MX", Contains: []string{"aspmx.l.google.com"}}, true, []string{"aspmx.l.google.com"}, ""},
+		{"Unknown name", "missing.nyu.edu.", DNSRecordAssertion{Type: "A"}, false, nil, "no A records"},
+		{"Unsupported type", "library.nyu.edu.", DNSRecordAssertion{Type: "SRV"}, false, nil, `unsupported record type "SRV"`},
+		{"Invalid regex", "library.nyu.edu.", DNSRecordAssertion{Type: "TXT", Regex: "("}, false, []string{"v=spf1 include:_spf.google.com ~all"}, "invalid regex"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			result := test.assertion.evaluate(t.Context(), check, resolver, test.name)
+			assert.Equal(t, test.wantOk, result.Ok)
+			assert.Equal(t, test.wantActual, result.Actual)
+			if test.wantErr != "" {
+				assert.Contains(t, result.Err, test.wantErr)
+			} else {
+				assert.Empty(t, result.Err)
+			}
+		})
+	}
+}
+
+func TestGetStatus_DNS(t *testing.T) {
+	server := testDNSServer(t)
+
+	app := &Application{
+		Name:    "libguides-dns",
+		Type:    CheckDNS,
+		Timeout: 2 * time.Second,
+		DNS: &DNSCheck{
+			Name:     "libguides.nyu.edu",
+			Resolver: server,
+			Records: []DNSRecordAssertion{
+				{Type: "CNAME", Equals: []string{"nyu.libguides.com"}},
+				{Type: "A", Contains: []string{"54.85.10.9"}},
+			},
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk)
+	assert.False(t, status.IsOk(), "a failing record assertion fails the check")
+	assert.Positive(t, status.Timings.Total, "lookup latency is measured")
+	lines := strings.Split(status.String(), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^Success: DNS libguides\.nyu\.edu answered in \S+$`, lines[0])
+	assert.Equal(t, "Success: DNS CNAME libguides.nyu.edu equals [nyu.libguides.com]", lines[1])
+	assert.Equal(t, "Failure: DNS A libguides.nyu.edu contains [54.85.10.9], actual: [54.85.10.1 54.85.10.2]", lines[2])
+}
+
+func TestGetStatus_DNSDoesNotResolve(t *testing.T) {
+	app := &Application{
+		Name:    "gone",
+		Type:    CheckDNS,
+		Timeout: 2 * time.Second,
+		DNS:     &DNSCheck{Name: "gone.nyu.edu", Resolver: testDNSServer(t)},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.False(t, status.IsOk())
+	assert.Contains(t, status.String(), "Failure: DNS gone.nyu.edu did not resolve: lookup gone.nyu.edu")
+}
+
+func TestGetStatus_DNSLookupTime(t *testing.T) {
+	app := &Application{
+		Name:            "library-dns",
+		Type:            CheckDNS,
+		Timeout:         2 * time.Second,
+		MaxResponseTime: time.Nanosecond,
+		DNS:             &DNSCheck{Name: "library.nyu.edu", Resolver: testDNSServer(t)},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk)
+	assert.True(t, status.ResponseTimeExceeded)
+	assert.False(t, status.IsOk())
+	assert.Regexp(t, `^Failure: DNS library\.nyu\.edu answered in \S+, over max_response_time 1ns$`, status.String())
+}
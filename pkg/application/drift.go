@@ -0,0 +1,250 @@
+package application
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Drift modes for drift.mode
+const (
+	DriftAny  = "any"
+	DriftSize = "size"
+)
+
+// shortHashLength is how much of a SHA-256 digest is shown in output.
+const shortHashLength = 12
+
+// DriftCheck compares the final page's body against a baseline recorded on an earlier run.
+// Mode any (the default) reports any change of the SHA-256; mode size only reports a change
+// in size of more than size_change_percent. With normalize, runs of whitespace are collapsed
+// before hashing, so re-indenting a file is not drift.
+type DriftCheck struct {
+	Mode              string  `yaml:"mode"`
+	SizeChangePercent float64 `yaml:"size_change_percent"`
+	Normalize         bool    `yaml:"normalize"`
+}
+
+// Fingerprint identifies a response body by its SHA-256 and size.
+type Fingerprint struct {
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+}
+
+// DriftBaseline is the fingerprint recorded for an application the first time it was checked.
+type DriftBaseline struct {
+	Fingerprint
+	URL        string    `json:"url"`
+	Normalized bool      `json:"normalized"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// DriftResult is the outcome of comparing a fingerprint with the application's baseline.
+// Recorded is set when there was no baseline to compare with and one was recorded instead.
+type DriftResult struct {
+	Baseline   DriftBaseline
+	Actual     Fingerprint
+	Recorded   bool
+	Ok         bool
+	SizeChange float64 // in percent of the baseline size
+	Err        string
+}
+
+// DriftBaselines is the state file of recorded baselines, keyed by application name.
+// Baselines are kept until they are forgotten, e.g. with aswa accept-drift, or the check's
+// URL or normalize setting changes.
+type DriftBaselines struct {
+	path      string
+	Baselines map[string]DriftBaseline `json:"baselines"`
+	changed   bool
+}
+
+// fingerprint hashes the body the way the drift check is configured to.
+func (check DriftCheck) fingerprint(body string) Fingerprint {
+	if check.Normalize {
+		body = strings.Join(strings.Fields(body), " ")
+	}
+	sum := sha256.Sum256([]byte(body))
+	return Fingerprint{SHA256: hex.EncodeToString(sum[:]), Size: len(body)}
+}
+
+func (check DriftCheck) mode() string {
+	if check.Mode == "" {
+		return DriftAny
+	}
+	return check.Mode
+}
+
+// LoadDriftBaselines reads the state file at path. A missing file is an empty state.
+func LoadDriftBaselines(path string) (*DriftBaselines, error) {
+	baselines := &DriftBaselines{path: path, Baselines: map[string]DriftBaseline{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return baselines, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading drift state %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, baselines); err != nil {
+		return nil, fmt.Errorf("parsing drift state %s: %w", path, err)
+	}
+	if baselines.Baselines == nil {
+		baselines.Baselines = map[string]DriftBaseline{}
+	}
+	return baselines, nil
+}
+
+// Evaluate compares the status's fingerprint with the application's baseline and sets
+// status.Drift. When there is no baseline yet, one is recorded only if the rest of the
+// check passed, so an error page never becomes the baseline.
+func (baselines *DriftBaselines) Evaluate(status *AppCheckStatus, now time.Time) {
+	app := status.Application
+	if app == nil || app.Drift == nil || status.Fingerprint == nil {
+		return
+	}
+	check := *app.Drift
+	actual := *status.Fingerprint
+
+	if check.mode() != DriftAny && check.mode() != DriftSize {
+		status.Drift = &DriftResult{Actual: actual, Err: fmt.Sprintf("unknown drift mode %q", check.Mode)}
+		return
+	}
+
+	baseline, ok := baselines.Baselines[app.Name]
+	if !ok || baseline.URL != app.URL || baseline.Normalized != check.Normalize {
+		if !status.IsOk() {
+			return
+		}
+		baseline = DriftBaseline{Fingerprint: actual, URL: app.URL, Normalized: check.Normalize, RecordedAt: now.UTC()}
+		baselines.Baselines[app.Name] = baseline
+		baselines.changed = true
+		status.Drift = &DriftResult{Baseline: baseline, Actual: actual, Recorded: true, Ok: true}
+		return
+	}
+
+	result := &DriftResult{Baseline: baseline, Actual: actual, SizeChange: sizeChange(baseline.Size, actual.Size)}
+	switch check.mode() {
+	case DriftSize:
+		result.Ok = math.Abs(result.SizeChange) <= check.SizeChangePercent
+	default:
+		result.Ok = actual == baseline.Fingerprint
+	}
+	status.Drift = result
+}
+
+// CheckWritable reports an error when the state file could not be saved: a file cannot be created
+// in its directory or, when the directory does not exist yet, in the closest parent it would be
+// created in.
+func (baselines *DriftBaselines) CheckWritable() error {
+	dir := filepath.Dir(baselines.path)
+	for {
+		info, err := os.Stat(dir)
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && filepath.Dir(dir) != dir:
+			dir = filepath.Dir(dir)
+			continue
+		case err != nil:
+			return fmt.Errorf("drift state %s cannot be written: %w", baselines.path, err)
+		case !info.IsDir():
+			return fmt.Errorf("drift state %s cannot be written: %s is not a directory", baselines.path, dir)
+		}
+		file, err := os.CreateTemp(dir, ".aswa-write-check-*")
+		if err != nil {
+			return fmt.Errorf("drift state %s cannot be written: %w", baselines.path, err)
+		}
+		file.Close()
+		return os.Remove(file.Name())
+	}
+}
+
+// Forget removes the application's baseline, so the next passing check records a new one.
+// It reports whether there was a baseline to remove.
+func (baselines *DriftBaselines) Forget(name string) bool {
+	if _, ok := baselines.Baselines[name]; !ok {
+		return false
+	}
+	delete(baselines.Baselines, name)
+	baselines.changed = true
+	return true
+}
+
+// Save writes the state file if a baseline was recorded or forgotten since it was loaded.
+func (baselines *DriftBaselines) Save() error {
+	if !baselines.changed {
+		return nil
+	}
+	data, err := json.MarshalIndent(baselines, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(baselines.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("writing drift state %s: %w", baselines.path, err)
+		}
+	}
+	// Write to a temporary file first so an interrupted run cannot leave a truncated state file.
+	tmp := baselines.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing drift state %s: %w", baselines.path, err)
+	}
+	if err := os.Rename(tmp, baselines.path); err != nil {
+		return fmt.Errorf("writing drift state %s: %w", baselines.path, err)
+	}
+	baselines.changed = false
+	return nil
+}
+
+// sizeChange returns how much actual differs from baseline, in percent. Any change from
+// an empty baseline counts as a change of 100%.
+func sizeChange(baseline, actual int) float64 {
+	if baseline == 0 {
+		if actual == 0 {
+			return 0
+		}
+		return 100
+	}
+	return float64(actual-baseline) / float64(baseline) * 100
+}
+
+func driftResultOk(result *DriftResult) bool {
+	return result == nil || result.Ok
+}
+
+func shortHash(hash string) string {
+	if len(hash) > shortHashLength {
+		return hash[:shortHashLength]
+	}
+	return hash
+}
+
+// driftResultString reports a drift result, e.g.
+// "Failure: Drift URL https://cdn.library.nyu.edu/bess-vue/app.min.js changed since 2026-03-01: sha256 3b1f…, 48211 bytes, baseline sha256 9ac2…, 48390 bytes".
+func driftResultString(app *Application, result DriftResult) string {
+	target := "Drift URL " + app.URL
+	actual := fmt.Sprintf("sha256 %s, %d bytes", shortHash(result.Actual.SHA256), result.Actual.Size)
+	baseline := fmt.Sprintf("sha256 %s, %d bytes", shortHash(result.Baseline.SHA256), result.Baseline.Size)
+	since := result.Baseline.RecordedAt.Format(time.DateOnly)
+
+	switch {
+	case result.Err != "":
+		return fmt.Sprintf("Failure: %s %s", target, result.Err)
+	case result.Recorded:
+		return fmt.Sprintf("Success: %s baseline recorded: %s", target, actual)
+	case app.Drift.mode() == DriftSize && result.Ok:
+		return fmt.Sprintf("Success: %s size %d bytes is within %g%% of baseline %d bytes from %s", target, result.Actual.Size, app.Drift.SizeChangePercent, result.Baseline.Size, since)
+	case app.Drift.mode() == DriftSize:
+		return fmt.Sprintf("Failure: %s size changed by %+.1f%% since %s, over %g%%: %d bytes, baseline %d bytes", target, result.SizeChange, since, app.Drift.SizeChangePercent, result.Actual.Size, result.Baseline.Size)
+	case result.Ok:
+		return fmt.Sprintf("Success: %s matches baseline from %s: %s", target, since, actual)
+	default:
+		return fmt.Sprintf("Failure: %s changed since %s: %s, baseline %s", target, since, actual, baseline)
+	}
+}
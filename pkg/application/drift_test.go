@@ -0,0 +1,229 @@
+package application
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDriftCheckUnmarshalYAML(t *testing.T) {
+	const config = `
+name: bess-vue
+url: 'https://cdn.library.nyu.edu/bess-vue/app.min.js'
+expected_status: 200
+drift:
+  mode: size
+  size_change_percent: 5
+  normalize: true
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+
+	require.NotNil(t, app.Drift)
+	assert.Equal(t, DriftCheck{Mode: DriftSize, SizeChangePercent: 5, Normalize: true}, *app.Drift)
+	assert.True(t, app.IsGet(), "drift needs the response body")
+}
+
+func TestDriftFingerprint(t *testing.T) {
+	raw := DriftCheck{}.fingerprint("var a = 1;\n\nvar b = 2;\n")
+	assert.Equal(t, 23, raw.Size)
+	assert.Len(t, raw.SHA256, 64)
+	assert.NotEqual(t, raw, DriftCheck{}.fingerprint("var a = 1;\n  var b = 2;"))
+
+	normalized := DriftCheck{Normalize: true}
+	assert.Equal(t, normalized.fingerprint("var a = 1;\n\nvar b = 2;\n"), normalized.fingerprint("  var a = 1;\n  var b = 2;"),
+		"whitespace changes are not drift once normalized")
+	assert.Equal(t, 21, normalized.fingerprint("var a = 1;\n\nvar b = 2;\n").Size)
+}
+
+func TestDriftBaselinesEvaluate(t *testing.T) {
+	const url = "https://cdn.library.nyu.edu/libguides/index.min.js"
+	recordedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	baseline := DriftCheck{}.fingerprint(strings.Repeat("x", 1000))
+
+	var tests = []struct {
+		description string
+		check       DriftCheck
+		body        string
+		wantOk      bool
+		want        string
+	}{
+		{
+			"Unchanged",
+			DriftCheck{},
+			strings.Repeat("x", 1000),
+			true,
+			fmt.Sprintf("Success: Drift URL %s matches baseline from 2026-03-01: sha256 %s, 1000 bytes", url, baseline.SHA256[:12]),
+		},
+		{
+			"Any change",
+			DriftCheck{Mode: DriftAny},
+			strings.Repeat("y", 1000),
+			false,
+			fmt.Sprintf("Failure: Drift URL %s changed since 2026-03-01: sha256 %s, 1000 bytes, baseline sha256 %s, 1000 bytes",
+				url, DriftCheck{}.fingerprint(strings.Repeat("y", 1000)).SHA256[:12], baseline.SHA256[:12]),
+		},
+		{
+			"Size within tolerance",
+			DriftCheck{Mode: DriftSize, SizeChangePercent: 10},
+			strings.Repeat("y", 1080),
+			true,
+			fmt.Sprintf("Success: Drift URL %s size 1080 bytes is within 10%% of baseline 1000 bytes from 2026-03-01", url),
+		},
+		{
+			"Size over tolerance",
+			DriftCheck{Mode: DriftSize, SizeChangePercent: 10},
+			strings.Repeat("y", 850),
+			false,
+			fmt.Sprintf("Failure: Drift URL %s size changed by -15.0%% since 2026-03-01, over 10%%: 850 bytes, baseline 1000 bytes", url),
+		},
+		{
+			"Emptied",
+			DriftCheck{Mode: DriftSize, SizeChangePercent: 50},
+			"",
+			false,
+			fmt.Sprintf("Failure: Drift URL %s size changed by -100.0%% since 2026-03-01, over 50%%: 0 bytes, baseline 1000 bytes", url),
+		},
+		{
+			"Unknown mode",
+			DriftCheck{Mode: "hash"},
+			strings.Repeat("x", 1000),
+			false,
+			fmt.Sprintf(`Failure: Drift URL %s unknown drift mode "hash"`, url),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			baselines := &DriftBaselines{Baselines: map[string]DriftBaseline{
+				"libguides-assets": {Fingerprint: baseline, URL: url, RecordedAt: recordedAt},
+			}}
+			app := &Application{Name: "libguides-assets", URL: url, Drift: &test.check}
+			fingerprint := test.check.fingerprint(test.body)
+			status := &AppCheckStatus{Application: app, StatusOk: true, StatusContentOk: true, StatusCSPOk: true, Fingerprint: &fingerprint}
+
+			baselines.Evaluate(status, recordedAt.Add(24*time.Hour))
+
+			require.NotNil(t, status.Drift)
+			assert.Equal(t, test.wantOk, status.IsOk())
+			assert.Equal(t, test.want, driftResultString(app, *status.Drift))
+			assert.Equal(t, baseline, baselines.Baselines["libguides-assets"].Fingerprint, "a drifted baseline is kept")
+		})
+	}
+}
+
+func TestDriftBaselinesRecordAndSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "drift.json")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	baselines, err := LoadDriftBaselines(path)
+	require.NoError(t, err)
+	assert.Empty(t, baselines.Baselines)
+
+	app := &Application{Name: "bess-vue", URL: "https://cdn.library.nyu.edu/bess-vue/app.min.js", Drift: &DriftCheck{}}
+	fingerprint := app.Drift.fingerprint("console.log('bess')")
+	status := &AppCheckStatus{Application: app, StatusOk: true, StatusContentOk: true, StatusCSPOk: true, Fingerprint: &fingerprint}
+
+	baselines.Evaluate(status, now)
+
+	require.NotNil(t, status.Drift)
+	assert.True(t, status.Drift.Recorded)
+	assert.True(t, status.IsOk())
+	assert.Equal(t, fmt.Sprintf("Success: Drift URL %s baseline recorded: sha256 %s, 19 bytes", app.URL, fingerprint.SHA256[:12]),
+		driftResultString(app, *status.Drift))
+	require.NoError(t, baselines.Save())
+
+	reloaded, err := LoadDriftBaselines(path)
+	require.NoError(t, err)
+	assert.Equal(t, DriftBaseline{Fingerprint: fingerprint, URL: app.URL, RecordedAt: now}, reloaded.Baselines["bess-vue"])
+
+	// A new URL replaces the baseline rather than reporting drift.
+	app.URL = "https://cdn.library.nyu.edu/bess-vue/v2/app.min.js"
+	reloaded.Evaluate(status, now)
+	assert.True(t, status.Drift.Recorded)
+	assert.Equal(t, app.URL, reloaded.Baselines["bess-vue"].URL)
+}
+
+func TestDriftBaselinesEvaluate_FailingCheckRecordsNoBaseline(t *testing.T) {
+	baselines := &DriftBaselines{Baselines: map[string]DriftBaseline{}}
+	app := &Application{Name: "bess-vue", URL: "https://cdn.library.nyu.edu/bess-vue/app.min.js", Drift: &DriftCheck{}}
+	fingerprint := app.Drift.fingerprint("<h1>503 Service Unavailable</h1>")
+	status := &AppCheckStatus{Application: app, StatusOk: false, StatusContentOk: true, StatusCSPOk: true, Fingerprint: &fingerprint}
+
+	baselines.Evaluate(status, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, status.Drift)
+	assert.Empty(t, baselines.Baselines)
+	assert.False(t, baselines.changed)
+}
+
+func TestDriftBaselinesForget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drift.json")
+	baselines := &DriftBaselines{path: path, Baselines: map[string]DriftBaseline{
+		"bess-vue": {Fingerprint: Fingerprint{SHA256: "9ac2", Size: 48390}, URL: "https://cdn.library.nyu.edu/bess-vue/app.min.js"},
+	}}
+
+	assert.False(t, baselines.Forget("libguides-assets"))
+	assert.True(t, baselines.Forget("bess-vue"))
+	require.NoError(t, baselines.Save())
+
+	reloaded, err := LoadDriftBaselines(path)
+	require.NoError(t, err)
+	assert.Empty(t, reloaded.Baselines)
+}
+
+func TestDriftBaselinesCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, path := range []string{
+		filepath.Join(dir, "drift.json"),
+		filepath.Join(dir, "not", "created", "yet", "drift.json"),
+	} {
+		baselines, err := LoadDriftBaselines(path)
+		require.NoError(t, err)
+		assert.NoError(t, baselines.CheckWritable(), "missing directories are created when the state is saved")
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the check leaves nothing behind")
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+	baselines := &DriftBaselines{path: filepath.Join(file, "state", "drift.json")}
+	assert.ErrorContains(t, baselines.CheckWritable(), "drift state "+baselines.path+" cannot be written")
+}
+
+func TestLoadDriftBaselines_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drift.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+
+	_, err := LoadDriftBaselines(path)
+
+	assert.ErrorContains(t, err, "parsing drift state "+path)
+}
+
+func TestGetStatus_DriftFingerprint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		fmt.Fprint(w, "console.log('libguides')")
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{Name: "libguides-assets", URL: srv.URL, ExpectedStatusCode: http.StatusOK, Timeout: 2 * time.Second, Drift: &DriftCheck{}}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	require.NotNil(t, status.Fingerprint)
+	assert.Equal(t, DriftCheck{}.fingerprint("console.log('libguides')"), *status.Fingerprint)
+	assert.Nil(t, status.Drift, "drift is evaluated against the state file by the caller")
+}
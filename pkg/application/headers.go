@@ -0,0 +1,120 @@
+package application
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HeaderAssertion is an expectation on one response header. In YAML it is either a
+// string (the exact value) or a mapping with one of equals, regex or present.
+// A header sent several times is compared as its values joined with ", ".
+type HeaderAssertion struct {
+	Equals  *string `yaml:"equals"`
+	Regex   string  `yaml:"regex"`
+	Present *bool   `yaml:"present"`
+}
+
+// HeaderResult is the outcome of one header assertion.
+type HeaderResult struct {
+	Name      string
+	Assertion HeaderAssertion
+	Ok        bool
+	Present   bool
+	Actual    string
+	Err       string
+}
+
+// UnmarshalYAML accepts a plain string as shorthand for an exact value.
+func (assertion *HeaderAssertion) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		value := node.Value
+		*assertion = HeaderAssertion{Equals: &value}
+		return nil
+	}
+	type plain HeaderAssertion
+	return node.Decode((*plain)(assertion))
+}
+
+// String describes the assertion as it appears in check output.
+func (assertion HeaderAssertion) String() string {
+	switch {
+	case assertion.Equals != nil:
+		return fmt.Sprintf("equals %q", *assertion.Equals)
+	case assertion.Regex != "":
+		return "matches /" + assertion.Regex + "/"
+	case assertion.Present != nil && !*assertion.Present:
+		return "is absent"
+	default:
+		return "is present"
+	}
+}
+
+// evaluateHeaders runs every header assertion against the response headers, in header name order.
+func evaluateHeaders(header http.Header, assertions map[string]HeaderAssertion) []HeaderResult {
+	if len(assertions) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(assertions))
+	for name := range assertions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	results := make([]HeaderResult, 0, len(names))
+	for _, name := range names {
+		results = append(results, assertions[name].evaluate(name, header))
+	}
+	return results
+}
+
+func (assertion HeaderAssertion) evaluate(name string, header http.Header) HeaderResult {
+	values := header.Values(name)
+	result := HeaderResult{
+		Name:      http.CanonicalHeaderKey(name),
+		Assertion: assertion,
+		Present:   len(values) > 0,
+		Actual:    strings.Join(values, ", "),
+	}
+
+	switch {
+	case assertion.Present != nil && !*assertion.Present:
+		result.Ok = !result.Present
+	case !result.Present:
+		result.Ok = false
+	case assertion.Equals != nil:
+		result.Ok = result.Actual == *assertion.Equals
+	case assertion.Regex != "":
+		re, err := regexp.Compile(assertion.Regex)
+		if err != nil {
+			result.Err = fmt.Sprintf("invalid regex: %v", err)
+			return result
+		}
+		result.Ok = re.MatchString(result.Actual)
+	default:
+		result.Ok = true
+	}
+	return result
+}
+
+func (result HeaderResult) passed() bool {
+	return result.Ok
+}
+
+// headerResultString formats one header assertion outcome, including the actual value on failure.
+func headerResultString(result HeaderResult) string {
+	switch {
+	case result.Err != "":
+		return fmt.Sprintf("Failure: Header %s %s could not be evaluated: %s", result.Name, result.Assertion, result.Err)
+	case result.Ok:
+		return fmt.Sprintf("Success: Header %s %s", result.Name, result.Assertion)
+	case !result.Present:
+		return fmt.Sprintf("Failure: Header %s %s, actual: missing", result.Name, result.Assertion)
+	default:
+		return fmt.Sprintf("Failure: Header %s %s, actual: %q", result.Name, result.Assertion, result.Actual)
+	}
+}
@@ -0,0 +1,201 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestHeaderAssertionsUnmarshalYAML(t *testing.T) {
+	const config = `
+expected_headers:
+  Access-Control-Allow-Origin: '*'
+  Strict-Transport-Security:
+    regex: 'max-age=\d+'
+  Content-Type:
+    equals: 'application/javascript'
+  X-Frame-Options:
+    present: true
+  X-Powered-By:
+    present: false
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+	require.Len(t, app.ExpectedHeaders, 5)
+
+	assert.Equal(t, "*", *app.ExpectedHeaders["Access-Control-Allow-Origin"].Equals)
+	assert.Equal(t, `max-age=\d+`, app.ExpectedHeaders["Strict-Transport-Security"].Regex)
+	assert.Equal(t, "application/javascript", *app.ExpectedHeaders["Content-Type"].Equals)
+	assert.True(t, *app.ExpectedHeaders["X-Frame-Options"].Present)
+	assert.False(t, *app.ExpectedHeaders["X-Powered-By"].Present)
+}
+
+func TestEvaluateHeaders(t *testing.T) {
+	str := func(s string) *string { return &s }
+	boolean := func(b bool) *bool { return &b }
+
+	header := http.Header{}
+	header.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+	header.Set("Access-Control-Allow-Origin", "*")
+	header.Set("Content-Type", "application/javascript")
+	header.Add("Cache-Control", "public")
+	header.Add("Cache-Control", "max-age=300")
+
+	var tests = []struct {
+		description string
+		name        string
+		assertion   HeaderAssertion
+		wantOk      bool
+		wantActual  string
+		wantErr     string
+	}{
+		{"Exact value", "Access-Control-Allow-Origin", HeaderAssertion{Equals: str("*")}, true, "*", ""},
+		{"Exact value mismatch", "Content-Type", HeaderAssertion{Equals: str("text/html")}, false, "application/javascript", ""},
+		{"Name is case-insensitive", "strict-transport-security", HeaderAssertion{Regex: `max-age=\d+`}, true, "max-age=31536000; includeSubDomains", ""},
+		{"Regex mismatch", "Strict-Transport-Security", HeaderAssertion{Regex: `preload`}, false, "max-age=31536000; includeSubDomains", ""},
+		{"Repeated header values are joined", "Cache-Control", HeaderAssertion{Equals: str("public, max-age=300")}, true, "public, max-age=300", ""},
+		{"Present", "Content-Type", HeaderAssertion{Present: boolean(true)}, true, "application/javascript", ""},
+		{"Mapping with nothing set means present", "Content-Type", HeaderAssertion{}, true, "application/javascript", ""},
+		{"Missing", "X-Frame-Options", HeaderAssertion{Present: boolean(true)}, false, "", ""},
+		{"Missing with exact value", "X-Frame-Options", HeaderAssertion{Equals: str("DENY")}, false, "", ""},
+		{"Absent", "X-Powered-By", HeaderAssertion{Present: boolean(false)}, true, "", ""},
+		{"Absent but present", "Content-Type", HeaderAssertion{Present: boolean(false)}, false, "application/javascript", ""},
+		{"Invalid regex", "Content-Type", HeaderAssertion{Regex: "("}, false, "application/javascript", "invalid regex"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			results := evaluateHeaders(header, map[string]HeaderAssertion{test.name: test.assertion})
+			require.Len(t, results, 1)
+			assert.Equal(t, test.wantOk, results[0].Ok)
+			assert.Equal(t, test.wantActual, results[0].Actual)
+			if test.wantErr != "" {
+				assert.Contains(t, results[0].Err, test.wantErr)
+			} else {
+				assert.Empty(t, results[0].Err)
+			}
+		})
+	}
+}
+
+func TestHeaderResultString(t *testing.T) {
+	exact := "DENY"
+	absent := false
+
+	var tests = []struct {
+		result HeaderResult
+		want   string
+	}{
+		{HeaderResult{Name: "X-Frame-Options", Assertion: HeaderAssertion{Equals: &exact}, Ok: true, Present: true, Actual: "DENY"}, `Success: Header X-Frame-Options equals "DENY"`},
+		{HeaderResult{Name: "X-Frame-Options", Assertion: HeaderAssertion{Equals: &exact}, Present: true, Actual: "SAMEORIGIN"}, `Failure: Header X-Frame-Options equals "DENY", actual: "SAMEORIGIN"`},
+		{HeaderResult{Name: "X-Frame-Options", Assertion: HeaderAssertion{Equals: &exact}}, `Failure: Header X-Frame-Options equals "DENY", actual: missing`},
+		{HeaderResult{Name: "Strict-Transport-Security", Assertion: HeaderAssertion{Regex: `max-age=\d+`}, Ok: true, Present: true}, `Success: Header Strict-Transport-Security matches /max-age=\d+/`},
+		{HeaderResult{Name: "X-Powered-By", Assertion: HeaderAssertion{Present: &absent}, Ok: true}, `Success: Header X-Powered-By is absent`},
+		{HeaderResult{Name: "X-Powered-By", Assertion: HeaderAssertion{Present: &absent}, Present: true, Actual: "PHP/8.1"}, `Failure: Header X-Powered-By is absent, actual: "PHP/8.1"`},
+		{HeaderResult{Name: "Content-Type", Assertion: HeaderAssertion{Regex: "("}, Present: true, Err: "invalid regex: boom"}, `Failure: Header Content-Type matches /(/ could not be evaluated: invalid regex: boom`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			assert.Equal(t, test.want, headerResultString(test.result))
+		})
+	}
+}
+
+func TestGetStatus_ExpectedHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "https://library.nyu.edu")
+		w.Header().Set("Content-Type", "application/javascript")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	anyOrigin := "*"
+	app := &Application{
+		Name:               "libguides-assets",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		ExpectedHeaders: map[string]HeaderAssertion{
+			"Content-Type":                {Regex: "javascript"},
+			"Access-Control-Allow-Origin": {Equals: &anyOrigin},
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk)
+	assert.False(t, status.IsOk(), "a failing header assertion fails the check")
+	assert.Equal(t, "Success: URL "+srv.URL+" resolved with 200\n"+
+		`Failure: Header Access-Control-Allow-Origin equals "*", actual: "https://library.nyu.edu"`+"\n"+
+		`Success: Header Content-Type matches /javascript/`,
+		outputWithoutTimings(status))
+}
+
+func TestGetStatus_ExpectedHeadersOnFinalPage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		if r.Method == http.MethodHead {
+			w.Header().Set("Cache-Control", "public")
+		}
+		_, _ = w.Write([]byte("NYU Libraries"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "library",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusFound,
+		Timeout:            2 * time.Second,
+		ExpectedContent:    ContentAssertions{{Value: "NYU Libraries"}},
+		ExpectedHeaders:    map[string]HeaderAssertion{"Cache-Control": {Regex: "no-store"}},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.IsOk(), "headers are checked on the final GET, not the redirect probed with HEAD: %s", status)
+}
+
+func TestGetStatus_ExpectedHeadersAlwaysOnFinalPage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write([]byte("NYU Libraries"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	headersOnly := Application{
+		Name:               "library",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusFound,
+		Timeout:            2 * time.Second,
+		ExpectedHeaders:    map[string]HeaderAssertion{"Cache-Control": {Regex: "no-store"}},
+	}
+	withContent := headersOnly
+	withContent.ExpectedContent = ContentAssertions{{Value: "NYU Libraries"}}
+
+	for _, app := range []Application{headersOnly, withContent} {
+		status := app.GetStatus()
+
+		require.NotNil(t, status)
+		assert.True(t, status.IsOk(), "headers are checked on the final page whatever else is asserted: %s", status)
+		require.Len(t, status.HeaderResults, 1)
+		assert.Equal(t, "no-store", status.HeaderResults[0].Actual)
+	}
+}
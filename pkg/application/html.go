@@ -0,0 +1,208 @@
+package application
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// maxHTMLActualLength caps how much element text is echoed back in failure output.
+const maxHTMLActualLength = 100
+
+// HTMLAssertion is an expectation on the elements selected from an HTML page by a CSS
+// selector or an XPath expression. With no comparison, at least one element must match.
+// text_contains and attribute_matches pass when any selected element satisfies them.
+type HTMLAssertion struct {
+	CSS              string  `yaml:"css"`
+	XPath            string  `yaml:"xpath"`
+	Exists           *bool   `yaml:"exists"`
+	Count            *int    `yaml:"count"`
+	MinCount         *int    `yaml:"min_count"`
+	TextContains     *string `yaml:"text_contains"`
+	Attribute        string  `yaml:"attribute"`
+	AttributeMatches *string `yaml:"attribute_matches"`
+}
+
+// HTMLResult is the outcome of one HTML assertion.
+type HTMLResult struct {
+	Assertion HTMLAssertion
+	Ok        bool
+	Actual    string // what was selected: an element count, texts or attribute values
+	Err       string // set when the selector or the assertion cannot be evaluated
+}
+
+func (result HTMLResult) passed() bool {
+	return result.Ok
+}
+
+// String describes the assertion as it appears in check output, e.g. `css input#searchBar exists`.
+func (assertion HTMLAssertion) String() string {
+	return assertion.selector() + " " + assertion.comparison()
+}
+
+func (assertion HTMLAssertion) selector() string {
+	if assertion.XPath != "" {
+		return "xpath " + assertion.XPath
+	}
+	return "css " + assertion.CSS
+}
+
+func (assertion HTMLAssertion) comparison() string {
+	switch {
+	case assertion.Count != nil:
+		return fmt.Sprintf("count == %d", *assertion.Count)
+	case assertion.MinCount != nil:
+		return fmt.Sprintf("count >= %d", *assertion.MinCount)
+	case assertion.TextContains != nil:
+		return fmt.Sprintf("text contains %q", *assertion.TextContains)
+	case assertion.AttributeMatches != nil:
+		return fmt.Sprintf("attribute %s matches /%s/", assertion.Attribute, *assertion.AttributeMatches)
+	case assertion.Exists != nil && !*assertion.Exists:
+		return "does not exist"
+	default:
+		return "exists"
+	}
+}
+
+// evaluateHTML parses the body and runs every HTML assertion against it.
+func evaluateHTML(body string, assertions []HTMLAssertion) (bool, []HTMLResult) {
+	if len(assertions) == 0 {
+		return true, nil
+	}
+
+	document, parseErr := htmlquery.Parse(strings.NewReader(body))
+
+	ok := true
+	results := make([]HTMLResult, 0, len(assertions))
+	for _, assertion := range assertions {
+		var result HTMLResult
+		if parseErr != nil {
+			result = HTMLResult{Assertion: assertion, Err: fmt.Sprintf("response is not valid HTML: %v", parseErr)}
+		} else {
+			result = assertion.evaluate(document)
+		}
+		ok = ok && result.Ok
+		results = append(results, result)
+	}
+	return ok, results
+}
+
+// selectNodes returns the elements matched by the assertion's CSS selector or XPath expression.
+func (assertion HTMLAssertion) selectNodes(document *html.Node) ([]*html.Node, error) {
+	switch {
+	case assertion.CSS != "" && assertion.XPath != "":
+		return nil, fmt.Errorf("set only one of css or xpath")
+	case assertion.XPath != "":
+		nodes, err := htmlquery.QueryAll(document, assertion.XPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xpath: %w", err)
+		}
+		return nodes, nil
+	case assertion.CSS != "":
+		selector, err := cascadia.Compile(assertion.CSS)
+		if err != nil {
+			return nil, fmt.Errorf("invalid css selector: %w", err)
+		}
+		return selector.MatchAll(document), nil
+	default:
+		return nil, fmt.Errorf("css or xpath is required")
+	}
+}
+
+// evaluate selects the assertion's elements from the parsed page and applies its comparison.
+func (assertion HTMLAssertion) evaluate(document *html.Node) HTMLResult {
+	result := HTMLResult{Assertion: assertion}
+
+	nodes, err := assertion.selectNodes(document)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+
+	switch {
+	case assertion.Count != nil:
+		result.Actual = elementCount(len(nodes))
+		result.Ok = len(nodes) == *assertion.Count
+	case assertion.MinCount != nil:
+		result.Actual = elementCount(len(nodes))
+		result.Ok = len(nodes) >= *assertion.MinCount
+	case assertion.TextContains != nil:
+		texts := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			text := normalizeText(htmlquery.InnerText(node))
+			texts = append(texts, text)
+			result.Ok = result.Ok || strings.Contains(text, *assertion.TextContains)
+		}
+		result.Actual = summarizeSelection(texts)
+	case assertion.AttributeMatches != nil:
+		if assertion.Attribute == "" {
+			result.Err = "attribute_matches requires attribute"
+			return result
+		}
+		re, err := regexp.Compile(*assertion.AttributeMatches)
+		if err != nil {
+			result.Err = fmt.Sprintf("invalid regex: %v", err)
+			return result
+		}
+		values := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			if !htmlquery.ExistsAttr(node, assertion.Attribute) {
+				continue
+			}
+			value := htmlquery.SelectAttr(node, assertion.Attribute)
+			values = append(values, value)
+			result.Ok = result.Ok || re.MatchString(value)
+		}
+		result.Actual = summarizeSelection(values)
+	case assertion.Exists != nil && !*assertion.Exists:
+		result.Actual = elementCount(len(nodes))
+		result.Ok = len(nodes) == 0
+	default:
+		result.Actual = elementCount(len(nodes))
+		result.Ok = len(nodes) > 0
+	}
+	return result
+}
+
+func elementCount(n int) string {
+	if n == 1 {
+		return "1 element"
+	}
+	return fmt.Sprintf("%d elements", n)
+}
+
+// normalizeText collapses runs of whitespace so element text reads on one line.
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// summarizeSelection renders selected texts or attribute values for failure output.
+func summarizeSelection(values []string) string {
+	if len(values) == 0 {
+		return "no match"
+	}
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		if len(value) > maxHTMLActualLength {
+			value = value[:maxHTMLActualLength] + "..."
+		}
+		quoted = append(quoted, strconv.Quote(value))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// htmlResultString formats one HTML assertion outcome, including what was selected on failure.
+func htmlResultString(result HTMLResult) string {
+	if result.Ok {
+		return fmt.Sprintf("Success: HTML %s", result.Assertion)
+	}
+	if result.Err != "" {
+		return fmt.Sprintf("Failure: HTML %s could not be evaluated: %s", result.Assertion, result.Err)
+	}
+	return fmt.Sprintf("Failure: HTML %s, actual: %s", result.Assertion, result.Actual)
+}
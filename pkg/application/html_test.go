@@ -0,0 +1,154 @@
+package application
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const searchPageHTML = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Research Guides: Home</title>
+  <script src="lib/bundle.js?version=af6f613389"></script>
+  <!-- <input id="searchBar"> is only mentioned in this comment -->
+</head>
+<body>
+  <form action="/search" class="search-form">
+    <input id="query" name="q" type="search" placeholder="Search   the
+      catalog">
+  </form>
+  <ul class="guides">
+    <li><a href="/arch">Architecture</a></li>
+    <li><a href="/music">Music</a></li>
+    <li><a href="https://example.com/history">History</a></li>
+  </ul>
+  <script>var searchBar = "not an element";</script>
+</body>
+</html>`
+
+func TestHTMLAssertionsUnmarshalYAML(t *testing.T) {
+	const config = `
+expected_html:
+  - css: 'form.search-form input[name="q"]'
+  - xpath: '//ul[@class="guides"]/li'
+    min_count: 2
+  - css: 'title'
+    text_contains: 'Research Guides'
+  - css: 'script[src]'
+    attribute: src
+    attribute_matches: 'bundle\.js\?version=[0-9a-f]+'
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+	require.Len(t, app.ExpectedHTML, 4)
+
+	assert.Equal(t, `form.search-form input[name="q"]`, app.ExpectedHTML[0].CSS)
+	assert.Equal(t, 2, *app.ExpectedHTML[1].MinCount)
+	assert.Equal(t, "Research Guides", *app.ExpectedHTML[2].TextContains)
+	assert.Equal(t, "src", app.ExpectedHTML[3].Attribute)
+	assert.True(t, app.IsGet(), "HTML assertions need the response body")
+}
+
+func TestEvaluateHTML(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+	boolean := func(b bool) *bool { return &b }
+
+	var tests = []struct {
+		description string
+		assertion   HTMLAssertion
+		wantOk      bool
+		wantActual  string
+		wantErr     string
+	}{
+		{"CSS element exists", HTMLAssertion{CSS: `input[type="search"]`}, true, "1 element", ""},
+		{"Text in comments and scripts is not an element", HTMLAssertion{CSS: "#searchBar"}, false, "0 elements", ""},
+		{"XPath element exists", HTMLAssertion{XPath: `//form[@action="/search"]`}, true, "1 element", ""},
+		{"Does not exist", HTMLAssertion{CSS: "div.error", Exists: boolean(false)}, true, "0 elements", ""},
+		{"Does not exist fails when present", HTMLAssertion{CSS: "form", Exists: boolean(false)}, false, "1 element", ""},
+		{"Exact count", HTMLAssertion{CSS: "ul.guides li", Count: num(3)}, true, "3 elements", ""},
+		{"Exact count fails", HTMLAssertion{XPath: "//ul/li", Count: num(2)}, false, "3 elements", ""},
+		{"Minimum count", HTMLAssertion{CSS: "ul.guides li", MinCount: num(2)}, true, "3 elements", ""},
+		{"Minimum count fails", HTMLAssertion{CSS: "ul.guides li", MinCount: num(5)}, false, "3 elements", ""},
+		{"Text contains", HTMLAssertion{CSS: "title", TextContains: str("Research Guides")}, true, `"Research Guides: Home"`, ""},
+		{"Text contains on any element", HTMLAssertion{CSS: "ul.guides a", TextContains: str("Music")}, true, `"Architecture", "Music", "History"`, ""},
+		{"Text contains fails", HTMLAssertion{XPath: "//title", TextContains: str("Service Unavailable")}, false, `"Research Guides: Home"`, ""},
+		{"Text contains with no element", HTMLAssertion{CSS: "h1", TextContains: str("Welcome")}, false, "no match", ""},
+		{"Attribute matches", HTMLAssertion{CSS: "script[src]", Attribute: "src", AttributeMatches: str(`^lib/bundle\.js\?version=[0-9a-f]+$`)}, true, `"lib/bundle.js?version=af6f613389"`, ""},
+		{"Attribute whitespace is kept", HTMLAssertion{CSS: "#query", Attribute: "placeholder", AttributeMatches: str(`^Search\s+the\s+catalog$`)}, true, "\"Search   the\\n      catalog\"", ""},
+		{"Attribute matches on any element", HTMLAssertion{CSS: "ul.guides a", Attribute: "href", AttributeMatches: str(`^https://`)}, true, `"/arch", "/music", "https://example.com/history"`, ""},
+		{"Attribute missing", HTMLAssertion{CSS: "form", Attribute: "method", AttributeMatches: str("post")}, false, "no match", ""},
+		{"Attribute matches needs attribute", HTMLAssertion{CSS: "form", AttributeMatches: str("post")}, false, "", "attribute_matches requires attribute"},
+		{"Invalid regex", HTMLAssertion{CSS: "form", Attribute: "action", AttributeMatches: str("(")}, false, "", "invalid regex"},
+		{"Invalid CSS", HTMLAssertion{CSS: "form[action"}, false, "", "invalid css selector"},
+		{"Invalid XPath", HTMLAssertion{XPath: "//form[@action"}, false, "", "invalid xpath"},
+		{"Selector required", HTMLAssertion{}, false, "", "css or xpath is required"},
+		{"Only one selector", HTMLAssertion{CSS: "form", XPath: "//form"}, false, "", "set only one of css or xpath"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			ok, results := evaluateHTML(searchPageHTML, []HTMLAssertion{test.assertion})
+			require.Len(t, results, 1)
+			assert.Equal(t, test.wantOk, ok)
+			assert.Equal(t, test.wantOk, results[0].Ok)
+			assert.Equal(t, test.wantActual, results[0].Actual)
+			if test.wantErr != "" {
+				assert.Contains(t, results[0].Err, test.wantErr)
+			} else {
+				assert.Empty(t, results[0].Err)
+			}
+		})
+	}
+}
+
+func TestHTMLResultString(t *testing.T) {
+	guides := "Research Guides"
+	assertion := HTMLAssertion{XPath: "//title", TextContains: &guides}
+
+	assert.Equal(t, `Success: HTML xpath //title text contains "Research Guides"`,
+		htmlResultString(HTMLResult{Assertion: assertion, Ok: true, Actual: `"Research Guides: Home"`}))
+	assert.Equal(t, `Failure: HTML xpath //title text contains "Research Guides", actual: "Service Unavailable"`,
+		htmlResultString(HTMLResult{Assertion: assertion, Actual: `"Service Unavailable"`}))
+	assert.Equal(t, `Failure: HTML css #searchBar exists, actual: 0 elements`,
+		htmlResultString(HTMLResult{Assertion: HTMLAssertion{CSS: "#searchBar"}, Actual: "0 elements"}))
+	assert.Equal(t, `Failure: HTML css form[action exists could not be evaluated: invalid css selector: boom`,
+		htmlResultString(HTMLResult{Assertion: HTMLAssertion{CSS: "form[action"}, Err: "invalid css selector: boom"}))
+}
+
+func TestGetStatus_HTMLAssertions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprint(w, searchPageHTML)
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "lib-guides",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		ExpectedHTML: []HTMLAssertion{
+			{CSS: `form.search-form input[name="q"]`},
+			{CSS: "#searchBar"},
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk)
+	assert.False(t, status.StatusContentOk, "a failing HTML assertion fails the content check")
+	require.Len(t, status.HTMLResults, 2)
+	assert.Equal(t, "Success: URL "+srv.URL+" resolved with 200\n"+
+		`Success: HTML css form.search-form input[name="q"] exists`+"\n"+
+		`Failure: HTML css #searchBar exists, actual: 0 elements`,
+		outputWithoutTimings(status))
+}
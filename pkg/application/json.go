@@ -0,0 +1,216 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JSONAssertion is an expectation on the value(s) selected from a JSON response body.
+// Every comparison set must pass, so gte and lte together check a range; when a selector
+// matches several values, every one of them must satisfy them.
+type JSONAssertion struct {
+	Path     string   `yaml:"path"`
+	Equals   *string  `yaml:"equals"`
+	Contains *string  `yaml:"contains"`
+	Regex    *string  `yaml:"regex"`
+	Exists   *bool    `yaml:"exists"`
+	Gt       *float64 `yaml:"gt"`
+	Gte      *float64 `yaml:"gte"`
+	Lt       *float64 `yaml:"lt"`
+	Lte      *float64 `yaml:"lte"`
+}
+
+// JSONResult is the outcome of one JSON assertion.
+type JSONResult struct {
+	Assertion JSONAssertion
+	Ok        bool
+	Actual    string // the selected values, rendered and comma separated
+	Err       string // set when the body or the assertion cannot be evaluated
+}
+
+func (result JSONResult) passed() bool {
+	return result.Ok
+}
+
+// String describes the assertion as it appears in check output, e.g. `$.status equals "operational"`.
+func (assertion JSONAssertion) String() string {
+	return assertion.Path + " " + assertion.comparison()
+}
+
+// comparison describes the comparisons set, e.g. `>= 1 and <= 5`.
+func (assertion JSONAssertion) comparison() string {
+	var comparisons []string
+	if assertion.Equals != nil {
+		comparisons = append(comparisons, fmt.Sprintf("equals %q", *assertion.Equals))
+	}
+	if assertion.Contains != nil {
+		comparisons = append(comparisons, fmt.Sprintf("contains %q", *assertion.Contains))
+	}
+	if assertion.Regex != nil {
+		comparisons = append(comparisons, "matches /"+*assertion.Regex+"/")
+	}
+	for _, bound := range assertion.bounds() {
+		if bound.limit != nil {
+			comparisons = append(comparisons, bound.operator+" "+formatNumber(*bound.limit))
+		}
+	}
+	switch {
+	case len(comparisons) > 0:
+		return strings.Join(comparisons, " and ")
+	case assertion.Exists != nil && !*assertion.Exists:
+		return "does not exist"
+	default:
+		return "exists"
+	}
+}
+
+// numericBound is one of the numeric comparisons of an assertion; limit is nil when it is not set.
+type numericBound struct {
+	operator string
+	limit    *float64
+	holds    func(number, limit float64) bool
+}
+
+func (assertion JSONAssertion) bounds() []numericBound {
+	return []numericBound{
+		{">", assertion.Gt, func(number, limit float64) bool { return number > limit }},
+		{">=", assertion.Gte, func(number, limit float64) bool { return number >= limit }},
+		{"<", assertion.Lt, func(number, limit float64) bool { return number < limit }},
+		{"<=", assertion.Lte, func(number, limit float64) bool { return number <= limit }},
+	}
+}
+
+// ValidateJSONPath reports why a JSONPath selector cannot be compiled, or nil when it can.
+func ValidateJSONPath(path string) error {
+	_, err := compileJSONPath(path)
+	return err
+}
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+// evaluateJSON parses the body and runs every JSON assertion against it.
+func evaluateJSON(body string, assertions []JSONAssertion) (bool, []JSONResult) {
+	if len(assertions) == 0 {
+		return true, nil
+	}
+
+	var document any
+	decodeErr := json.Unmarshal([]byte(body), &document)
+
+	ok := true
+	results := make([]JSONResult, 0, len(assertions))
+	for _, assertion := range assertions {
+		var result JSONResult
+		if decodeErr != nil {
+			result = JSONResult{Assertion: assertion, Err: fmt.Sprintf("response is not valid JSON: %v", decodeErr)}
+		} else {
+			result = assertion.evaluate(document)
+		}
+		ok = ok && result.Ok
+		results = append(results, result)
+	}
+	return ok, results
+}
+
+// evaluate selects the assertion's path from the decoded document and applies its comparison.
+func (assertion JSONAssertion) evaluate(document any) JSONResult {
+	result := JSONResult{Assertion: assertion}
+
+	path, err := compileJSONPath(assertion.Path)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+
+	values := path.selectNodes(document)
+	rendered := make([]string, 0, len(values))
+	for _, value := range values {
+		rendered = append(rendered, renderJSONValue(value))
+	}
+	result.Actual = strings.Join(rendered, ", ")
+
+	if assertion.Exists != nil && !*assertion.Exists {
+		result.Ok = len(values) == 0
+		return result
+	}
+	if len(values) == 0 {
+		result.Actual = "no match"
+		return result
+	}
+
+	var re *regexp.Regexp
+	if assertion.Regex != nil {
+		if re, err = regexp.Compile(*assertion.Regex); err != nil {
+			result.Err = fmt.Sprintf("invalid regex: %v", err)
+			return result
+		}
+	}
+
+	result.Ok = true
+	for i, value := range values {
+		if !assertion.matches(value, rendered[i], re) {
+			result.Ok = false
+			break
+		}
+	}
+	return result
+}
+
+// matches applies every comparison set to a single selected value.
+func (assertion JSONAssertion) matches(value any, rendered string, re *regexp.Regexp) bool {
+	if assertion.Equals != nil && rendered != *assertion.Equals {
+		return false
+	}
+	if assertion.Contains != nil && !jsonContains(value, rendered, *assertion.Contains) {
+		return false
+	}
+	if re != nil && !re.MatchString(rendered) {
+		return false
+	}
+	for _, bound := range assertion.bounds() {
+		if bound.limit == nil {
+			continue
+		}
+		number, ok := value.(float64)
+		if !ok {
+			var err error
+			if number, err = strconv.ParseFloat(rendered, 64); err != nil {
+				return false
+			}
+		}
+		if !bound.holds(number, *bound.limit) {
+			return false
+		}
+	}
+	// exists: being selected is enough
+	return true
+}
+
+// jsonContains reports whether a selected array has the element, or a selected value contains the text.
+func jsonContains(value any, rendered, text string) bool {
+	if array, ok := value.([]any); ok {
+		for _, element := range array {
+			if renderJSONValue(element) == text {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(rendered, text)
+}
+
+// jsonResultString formats one JSON assertion outcome, including the actual values on failure.
+func jsonResultString(result JSONResult) string {
+	if result.Ok {
+		return fmt.Sprintf("Success: JSON %s", result.Assertion)
+	}
+	if result.Err != "" {
+		return fmt.Sprintf("Failure: JSON %s could not be evaluated: %s", result.Assertion, result.Err)
+	}
+	return fmt.Sprintf("Failure: JSON %s, actual: %s", result.Assertion, result.Actual)
+}
@@ -0,0 +1,150 @@
+package application
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestJSONAssertionsUnmarshalYAML(t *testing.T) {
+	const config = `
+expected_json:
+  - path: '$.components[?(@.name=="Quay.io")].status'
+    equals: operational
+  - path: '$.page.id'
+    exists: true
+  - path: '$.components[0].position'
+    equals: 1
+  - path: '$.components[*].position'
+    lte: 3
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+	require.Len(t, app.ExpectedJSON, 4)
+
+	assert.Equal(t, "operational", *app.ExpectedJSON[0].Equals)
+	assert.True(t, *app.ExpectedJSON[1].Exists)
+	assert.Equal(t, "1", *app.ExpectedJSON[2].Equals, "numeric YAML scalars compare as their text")
+	assert.Equal(t, 3.0, *app.ExpectedJSON[3].Lte)
+	assert.True(t, app.IsGet(), "JSON assertions need the response body")
+}
+
+func TestEvaluateJSON(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+	boolean := func(b bool) *bool { return &b }
+
+	var tests = []struct {
+		description string
+		assertion   JSONAssertion
+		wantOk      bool
+		wantActual  string
+		wantErr     string
+	}{
+		{"Equals on filtered selector", JSONAssertion{Path: `$.components[?(@.name=="Quay.io")].status`, Equals: str("operational")}, true, "operational", ""},
+		{"Equals fails with actual value", JSONAssertion{Path: `$.components[?(@.name=="Registry")].status`, Equals: str("operational")}, false, "degraded_performance", ""},
+		{"Every selected value must match", JSONAssertion{Path: `$.components[*].status`, Equals: str("operational")}, false, "operational, degraded_performance, operational", ""},
+		{"Equals on a number", JSONAssertion{Path: `$.components[0].position`, Equals: str("1")}, true, "1", ""},
+		{"Contains substring", JSONAssertion{Path: `$.page.name`, Contains: str("Red")}, true, "Red Hat", ""},
+		{"Contains array element", JSONAssertion{Path: `$.components[1].components`, Contains: str("b")}, true, `["a","b"]`, ""},
+		{"Contains missing array element", JSONAssertion{Path: `$.components[1].components`, Contains: str("c")}, false, `["a","b"]`, ""},
+		{"Regex", JSONAssertion{Path: `$.page.updated_at`, Regex: str(`^\d{4}-\d{2}-\d{2}T`)}, true, "2026-10-17T00:00:00Z", ""},
+		{"Invalid regex", JSONAssertion{Path: `$.page.updated_at`, Regex: str(`(`)}, false, "2026-10-17T00:00:00Z", "invalid regex"},
+		{"Exists", JSONAssertion{Path: `$.page.id`, Exists: boolean(true)}, true, "8szqd6w4s277", ""},
+		{"Exists with no comparison", JSONAssertion{Path: `$.page.id`}, true, "8szqd6w4s277", ""},
+		{"Exists fails on no match", JSONAssertion{Path: `$.page.incident`, Exists: boolean(true)}, false, "no match", ""},
+		{"Does not exist", JSONAssertion{Path: `$.page.incident`, Exists: boolean(false)}, true, "", ""},
+		{"Does not exist fails when present", JSONAssertion{Path: `$.page.id`, Exists: boolean(false)}, false, "8szqd6w4s277", ""},
+		{"Greater than", JSONAssertion{Path: `$.components[*].position`, Gt: num(0)}, true, "1, 2, 3", ""},
+		{"Less than fails", JSONAssertion{Path: `$.components[*].position`, Lt: num(3)}, false, "1, 2, 3", ""},
+		{"Greater or equal", JSONAssertion{Path: `$.components[2].position`, Gte: num(3)}, true, "3", ""},
+		{"Less or equal", JSONAssertion{Path: `$.components[0].position`, Lte: num(1)}, true, "1", ""},
+		{"Range", JSONAssertion{Path: `$.components[*].position`, Gte: num(1), Lte: num(3)}, true, "1, 2, 3", ""},
+		{"Range fails on either bound", JSONAssertion{Path: `$.components[*].position`, Gte: num(1), Lte: num(2)}, false, "1, 2, 3", ""},
+		{"Equals and regex are both checked", JSONAssertion{Path: `$.page.name`, Equals: str("Red Hat"), Regex: str(`^Blue`)}, false, "Red Hat", ""},
+		{"Numeric comparison on text fails", JSONAssertion{Path: `$.page.name`, Gt: num(1)}, false, "Red Hat", ""},
+		{"Invalid path", JSONAssertion{Path: `components`, Equals: str("x")}, false, "", "must start with"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			ok, results := evaluateJSON(statusPageJSON, []JSONAssertion{test.assertion})
+			require.Len(t, results, 1)
+			assert.Equal(t, test.wantOk, ok)
+			assert.Equal(t, test.wantOk, results[0].Ok)
+			assert.Equal(t, test.wantActual, results[0].Actual)
+			if test.wantErr != "" {
+				assert.Contains(t, results[0].Err, test.wantErr)
+			} else {
+				assert.Empty(t, results[0].Err)
+			}
+		})
+	}
+}
+
+func TestEvaluateJSON_InvalidBody(t *testing.T) {
+	operational := "operational"
+	ok, results := evaluateJSON("<html>Service Unavailable</html>", []JSONAssertion{{Path: "$.status", Equals: &operational}})
+	assert.False(t, ok)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Err, "response is not valid JSON")
+
+	ok, results = evaluateJSON("<html></html>", nil)
+	assert.True(t, ok, "no assertions means nothing to fail")
+	assert.Nil(t, results)
+}
+
+func TestJSONResultString(t *testing.T) {
+	operational := "operational"
+	assertion := JSONAssertion{Path: `$.components[?(@.name=="Quay.io")].status`, Equals: &operational}
+
+	assert.Equal(t, `Success: JSON $.components[?(@.name=="Quay.io")].status equals "operational"`,
+		jsonResultString(JSONResult{Assertion: assertion, Ok: true, Actual: "operational"}))
+	assert.Equal(t, `Failure: JSON $.components[?(@.name=="Quay.io")].status equals "operational", actual: major_outage`,
+		jsonResultString(JSONResult{Assertion: assertion, Actual: "major_outage"}))
+	assert.Equal(t, `Failure: JSON $.components[?(@.name=="Quay.io")].status equals "operational" could not be evaluated: response is not valid JSON`,
+		jsonResultString(JSONResult{Assertion: assertion, Err: "response is not valid JSON"}))
+
+	low, high := 1.0, 5.0
+	assert.Equal(t, `Success: JSON $.meta.count >= 1 and <= 5`,
+		jsonResultString(JSONResult{Assertion: JSONAssertion{Path: "$.meta.count", Gte: &low, Lte: &high}, Ok: true, Actual: "3"}))
+}
+
+func TestGetStatus_JSONAssertions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, statusPageJSON)
+	}))
+	t.Cleanup(srv.Close)
+
+	operational := "operational"
+	app := &Application{
+		Name:               "redHat",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		ExpectedJSON: []JSONAssertion{
+			{Path: `$.components[?(@.name=="Quay.io")].status`, Equals: &operational},
+			{Path: `$.components[?(@.name=="Registry")].status`, Equals: &operational},
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk)
+	assert.False(t, status.StatusContentOk, "a failing JSON assertion fails the content check")
+	require.Len(t, status.JSONResults, 2)
+	assert.True(t, status.JSONResults[0].Ok)
+	assert.False(t, status.JSONResults[1].Ok)
+	assert.Equal(t, "Success: URL "+srv.URL+" resolved with 200\n"+
+		`Success: JSON $.components[?(@.name=="Quay.io")].status equals "operational"`+"\n"+
+		`Failure: JSON $.components[?(@.name=="Registry")].status equals "operational", actual: degraded_performance`,
+		outputWithoutTimings(status))
+}
@@ -0,0 +1,407 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// This file implements the subset of JSONPath used by expected_json selectors:
+//
+//	$                 the root (optional; jq-style paths such as .a.b are accepted too)
+//	.name ['name']    a member of an object
+//	[0] [-1]          an element of an array (negative indexes count from the end)
+//	.* [*]            every member or element
+//	..name            recursive descent: name at any depth
+//	[?(@.a.b == 'x')] the elements whose child path compares with a literal (==, !=, <, <=, >, >=),
+//	[?(@.a)]          or simply exists
+
+// jsonPathSegment selects the next set of nodes from the current ones.
+type jsonPathSegment interface {
+	apply(nodes []any) []any
+}
+
+type memberSegment struct{ name string }
+type indexSegment struct{ index int }
+type wildcardSegment struct{}
+type recursiveSegment struct{ name string }
+type filterSegment struct {
+	path     []string
+	operator string
+	literal  any
+}
+
+// jsonPath is a compiled selector.
+type jsonPath struct {
+	segments []jsonPathSegment
+}
+
+// compileJSONPath parses a selector into segments.
+func compileJSONPath(path string) (*jsonPath, error) {
+	p := &jsonPathParser{input: strings.TrimSpace(path)}
+	if p.input == "" {
+		return nil, fmt.Errorf("empty JSONPath")
+	}
+	if strings.HasPrefix(p.input, "$") {
+		p.pos = 1
+	} else if !strings.HasPrefix(p.input, ".") && !strings.HasPrefix(p.input, "[") {
+		return nil, fmt.Errorf("JSONPath %q must start with $, . or [", path)
+	}
+
+	var compiled jsonPath
+	for !p.done() {
+		segment, err := p.next()
+		if err != nil {
+			return nil, fmt.Errorf("JSONPath %q: %w", path, err)
+		}
+		compiled.segments = append(compiled.segments, segment)
+	}
+	return &compiled, nil
+}
+
+// selectNodes returns every node in document matched by the selector.
+func (path *jsonPath) selectNodes(document any) []any {
+	nodes := []any{document}
+	for _, segment := range path.segments {
+		nodes = segment.apply(nodes)
+	}
+	return nodes
+}
+
+func (s memberSegment) apply(nodes []any) []any {
+	var out []any
+	for _, node := range nodes {
+		if object, ok := node.(map[string]any); ok {
+			if value, ok := object[s.name]; ok {
+				out = append(out, value)
+			}
+		}
+	}
+	return out
+}
+
+func (s indexSegment) apply(nodes []any) []any {
+	var out []any
+	for _, node := range nodes {
+		if array, ok := node.([]any); ok {
+			i := s.index
+			if i < 0 {
+				i += len(array)
+			}
+			if i >= 0 && i < len(array) {
+				out = append(out, array[i])
+			}
+		}
+	}
+	return out
+}
+
+func (wildcardSegment) apply(nodes []any) []any {
+	var out []any
+	for _, node := range nodes {
+		out = append(out, children(node)...)
+	}
+	return out
+}
+
+func (s recursiveSegment) apply(nodes []any) []any {
+	var out []any
+	var walk func(node any)
+	walk = func(node any) {
+		if object, ok := node.(map[string]any); ok {
+			if value, ok := object[s.name]; ok {
+				out = append(out, value)
+			}
+		}
+		for _, child := range children(node) {
+			walk(child)
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+	return out
+}
+
+func (s filterSegment) apply(nodes []any) []any {
+	var out []any
+	for _, node := range nodes {
+		for _, child := range children(node) {
+			if s.matches(child) {
+				out = append(out, child)
+			}
+		}
+	}
+	return out
+}
+
+func (s filterSegment) matches(node any) bool {
+	current := []any{node}
+	for _, name := range s.path {
+		current = memberSegment{name: name}.apply(current)
+	}
+	if len(current) == 0 {
+		return false
+	}
+	if s.operator == "" {
+		return true
+	}
+	return compareJSONValues(current[0], s.operator, s.literal)
+}
+
+// children returns the members of an object (in key order) or the elements of an array.
+func children(node any) []any {
+	switch value := node.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		out := make([]any, 0, len(keys))
+		for _, key := range keys {
+			out = append(out, value[key])
+		}
+		return out
+	case []any:
+		return value
+	default:
+		return nil
+	}
+}
+
+// compareJSONValues compares a document value with a filter literal.
+// Numbers compare numerically; everything else compares by its rendered form.
+func compareJSONValues(actual any, operator string, literal any) bool {
+	actualNumber, actualIsNumber := actual.(float64)
+	literalNumber, literalIsNumber := literal.(float64)
+	if actualIsNumber && literalIsNumber {
+		switch operator {
+		case "==":
+			return actualNumber == literalNumber
+		case "!=":
+			return actualNumber != literalNumber
+		case "<":
+			return actualNumber < literalNumber
+		case "<=":
+			return actualNumber <= literalNumber
+		case ">":
+			return actualNumber > literalNumber
+		case ">=":
+			return actualNumber >= literalNumber
+		}
+		return false
+	}
+
+	switch operator {
+	case "==":
+		return renderJSONValue(actual) == renderJSONValue(literal)
+	case "!=":
+		return renderJSONValue(actual) != renderJSONValue(literal)
+	default:
+		return false
+	}
+}
+
+// renderJSONValue formats a decoded JSON value for comparison and output:
+// strings as-is, numbers without trailing zeros, and objects or arrays as compact JSON.
+func renderJSONValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// jsonPathParser is a small hand-written scanner over a selector string.
+type jsonPathParser struct {
+	input string
+	pos   int
+}
+
+func (p *jsonPathParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *jsonPathParser) peek(prefix string) bool {
+	return strings.HasPrefix(p.input[p.pos:], prefix)
+}
+
+func (p *jsonPathParser) next() (jsonPathSegment, error) {
+	switch {
+	case p.peek(".."):
+		p.pos += 2
+		name := p.readName()
+		if name == "" {
+			return nil, fmt.Errorf("expected a member name after .. at offset %d", p.pos)
+		}
+		return recursiveSegment{name: name}, nil
+	case p.peek(".*"):
+		p.pos += 2
+		return wildcardSegment{}, nil
+	case p.peek("."):
+		p.pos++
+		name := p.readName()
+		if name == "" {
+			return nil, fmt.Errorf("expected a member name at offset %d", p.pos)
+		}
+		return memberSegment{name: name}, nil
+	case p.peek("["):
+		return p.readBracket()
+	default:
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.input[p.pos], p.pos)
+	}
+}
+
+// readName reads a dot-notation member name.
+func (p *jsonPathParser) readName() string {
+	start := p.pos
+	for !p.done() && !strings.ContainsRune(".[ =!<>)", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *jsonPathParser) readBracket() (jsonPathSegment, error) {
+	end := p.matchingBracket()
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated [ at offset %d", p.pos)
+	}
+	inner := strings.TrimSpace(p.input[p.pos+1 : end])
+	p.pos = end + 1
+
+	switch {
+	case inner == "*":
+		return wildcardSegment{}, nil
+	case strings.HasPrefix(inner, "?"):
+		return parseFilter(inner)
+	case isQuoted(inner):
+		return memberSegment{name: inner[1 : len(inner)-1]}, nil
+	default:
+		index, err := strconv.Atoi(inner)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q", inner)
+		}
+		return indexSegment{index: index}, nil
+	}
+}
+
+// matchingBracket returns the offset of the ] closing the [ at the current position,
+// skipping brackets inside quoted strings.
+func (p *jsonPathParser) matchingBracket() int {
+	depth := 0
+	var quote byte
+	for i := p.pos; i < len(p.input); i++ {
+		c := p.input[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseFilter parses the inside of [?(...)].
+func parseFilter(inner string) (jsonPathSegment, error) {
+	expression := strings.TrimSpace(strings.TrimPrefix(inner, "?"))
+	if !strings.HasPrefix(expression, "(") || !strings.HasSuffix(expression, ")") {
+		return nil, fmt.Errorf("filter %q must be written as ?(...)", inner)
+	}
+	expression = strings.TrimSpace(expression[1 : len(expression)-1])
+	if !strings.HasPrefix(expression, "@") {
+		return nil, fmt.Errorf("filter %q must start with @", inner)
+	}
+
+	var filter filterSegment
+	left, operator, right := expression, "", ""
+	if i, op := filterOperator(expression); op != "" {
+		left, operator, right = strings.TrimSpace(expression[:i]), op, strings.TrimSpace(expression[i+len(op):])
+	}
+
+	for _, name := range strings.Split(strings.TrimPrefix(left, "@"), ".") {
+		if name != "" {
+			filter.path = append(filter.path, name)
+		}
+	}
+	if len(filter.path) == 0 {
+		return nil, fmt.Errorf("filter %q has no member to test", inner)
+	}
+
+	if operator != "" {
+		literal, err := parseFilterLiteral(right)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q: %w", inner, err)
+		}
+		filter.operator, filter.literal = operator, literal
+	}
+	return filter, nil
+}
+
+// filterOperator returns the first comparison operator in a filter expression and its offset,
+// skipping operators inside quoted literals, e.g. the < in @.name == 'a<b'.
+func filterOperator(expression string) (int, string) {
+	var quote byte
+	for i := 0; i < len(expression); i++ {
+		c := expression[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		default:
+			for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+				if strings.HasPrefix(expression[i:], op) {
+					return i, op
+				}
+			}
+		}
+	}
+	return -1, ""
+}
+
+func parseFilterLiteral(raw string) (any, error) {
+	switch {
+	case isQuoted(raw):
+		return raw[1 : len(raw)-1], nil
+	case raw == "true" || raw == "false":
+		return raw == "true", nil
+	case raw == "null":
+		return nil, nil
+	default:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid literal %q", raw)
+		}
+		return number, nil
+	}
+}
+
+func isQuoted(s string) bool {
+	return len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]
+}
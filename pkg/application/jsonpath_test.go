@@ -0,0 +1,92 @@
+package application
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const statusPageJSON = `{
+  "page": {"id": "8szqd6w4s277", "name": "Red Hat", "updated_at": "2026-10-17T00:00:00Z"},
+  "components": [
+    {"name": "Quay.io", "status": "operational", "position": 1, "group": false},
+    {"name": "Registry", "status": "degraded_performance", "position": 2, "group": true, "components": ["a", "b"]},
+    {"name": "OpenShift", "status": "operational", "position": 3, "group": false}
+  ]
+}`
+
+func decodeTestJSON(t *testing.T, raw string) any {
+	t.Helper()
+	var document any
+	require.NoError(t, json.Unmarshal([]byte(raw), &document))
+	return document
+}
+
+func TestJSONPathSelect(t *testing.T) {
+	document := decodeTestJSON(t, statusPageJSON)
+
+	var tests = []struct {
+		description string
+		path        string
+		want        []string
+	}{
+		{"Root member", "$.page.name", []string{"Red Hat"}},
+		{"jq-style path without $", ".page.name", []string{"Red Hat"}},
+		{"Bracket member", "$['page']['id']", []string{"8szqd6w4s277"}},
+		{"Array index", "$.components[0].name", []string{"Quay.io"}},
+		{"Negative array index", "$.components[-1].name", []string{"OpenShift"}},
+		{"Out of range index", "$.components[7].name", nil},
+		{"Wildcard over array", "$.components[*].status", []string{"operational", "degraded_performance", "operational"}},
+		{"Wildcard over object members are in key order", "$.page.*", []string{"8szqd6w4s277", "Red Hat", "2026-10-17T00:00:00Z"}},
+		{"Recursive descent", "$..position", []string{"1", "2", "3"}},
+		{"Filter on string equality", `$.components[?(@.name=="Quay.io")].status`, []string{"operational"}},
+		{"Filter with single quotes", `$.components[?(@.name == 'Registry')].status`, []string{"degraded_performance"}},
+		{"Filter on inequality", `$.components[?(@.status != 'operational')].name`, []string{"Registry"}},
+		{"Filter on number", `$.components[?(@.position >= 2)].name`, []string{"Registry", "OpenShift"}},
+		{"Filter on bool", `$.components[?(@.group == true)].name`, []string{"Registry"}},
+		{"Filter on existence", `$.components[?(@.components)].name`, []string{"Registry"}},
+		{"Operator inside a quoted literal", `$.components[?(@.status != 'a==b')].name`, []string{"Quay.io", "Registry", "OpenShift"}},
+		{"Comparison inside a quoted literal", `$.components[?(@.name=='a<b')].name`, nil},
+		{"Array value is rendered as JSON", `$.components[1].components`, []string{`["a","b"]`}},
+		{"Missing member", "$.page.missing", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			path, err := compileJSONPath(test.path)
+			require.NoError(t, err)
+
+			var got []string
+			for _, value := range path.selectNodes(document) {
+				got = append(got, renderJSONValue(value))
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestCompileJSONPathErrors(t *testing.T) {
+	var tests = []struct {
+		description string
+		path        string
+		wantErr     string
+	}{
+		{"Empty", "", "empty JSONPath"},
+		{"Bad start", "components", "must start with $, . or ["},
+		{"Unterminated bracket", "$.components[0", "unterminated ["},
+		{"Bad index", "$.components[first]", `invalid index "first"`},
+		{"Filter without parentheses", "$.components[?@.name]", "must be written as ?(...)"},
+		{"Filter without @", "$.components[?(name == 'x')]", "must start with @"},
+		{"Filter with bad literal", "$.components[?(@.name == Quay)]", `invalid literal "Quay"`},
+		{"Trailing dot", "$.page.", "expected a member name"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := compileJSONPath(test.path)
+			assert.ErrorContains(t, err, test.wantErr)
+		})
+	}
+}
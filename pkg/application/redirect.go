@@ -0,0 +1,173 @@
+package application
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// RedirectExpectation describes one expected redirect hop. Status 0 accepts any redirect
+// status; location is compared like expected_location, location_regex as a Go regular
+// expression against the absolute URL redirected to.
+type RedirectExpectation struct {
+	Status        int    `yaml:"status"`
+	Location      string `yaml:"location"`
+	LocationRegex string `yaml:"location_regex"`
+}
+
+// RedirectHop is one response observed while following redirects. Location is the
+// absolute URL redirected to, and is empty for the final response.
+type RedirectHop struct {
+	URL      string
+	Status   int
+	Location string
+}
+
+// Kinds of redirect result.
+const (
+	redirectHop      = "hop"
+	redirectFinalURL = "final_url"
+)
+
+// RedirectResult is the outcome of one expected hop or of the expected final URL.
+type RedirectResult struct {
+	Kind     string
+	Hop      int // 1-based, for redirectHop results
+	Ok       bool
+	Expected string
+	Actual   string
+}
+
+// String describes the expected hop, e.g. "301 -> https://search.library.nyu.edu/".
+func (expectation RedirectExpectation) String() string {
+	status := "3xx"
+	if expectation.Status != 0 {
+		status = fmt.Sprint(expectation.Status)
+	}
+	switch {
+	case expectation.LocationRegex != "":
+		return status + " -> /" + expectation.LocationRegex + "/"
+	case expectation.Location != "":
+		return status + " -> " + expectation.Location
+	default:
+		return status
+	}
+}
+
+// String describes the observed hop, e.g. "301 -> https://search.library.nyu.edu/".
+func (hop RedirectHop) String() string {
+	if hop.Location == "" {
+		return fmt.Sprint(hop.Status)
+	}
+	return fmt.Sprintf("%d -> %s", hop.Status, hop.Location)
+}
+
+// followsRedirects reports whether the check asserts on the redirect chain.
+func (test Application) followsRedirects() bool {
+	return len(test.ExpectedRedirects) > 0 || test.ExpectedFinalURL != ""
+}
+
+// redirectLimit returns how many redirects are followed: max_redirects, or the default when it is not set.
+func (test Application) redirectLimit() int {
+	if test.MaxRedirects <= 0 {
+		return defaultMaxRedirects
+	}
+	return test.MaxRedirects
+}
+
+// recordRedirect appends the redirect response that led to req to the chain.
+func recordRedirect(test Application, chain *[]RedirectHop, req *http.Request, via []*http.Request) {
+	hop := RedirectHop{URL: test.Auth.redactURL(via[len(via)-1].URL), Location: test.Auth.redactURL(req.URL)}
+	if req.Response != nil {
+		hop.Status = req.Response.StatusCode
+	}
+	*chain = append(*chain, hop)
+}
+
+// matches reports whether an observed redirect hop satisfies the expectation.
+func (expectation RedirectExpectation) matches(hop RedirectHop) (bool, error) {
+	if expectation.Status != 0 && hop.Status != expectation.Status {
+		return false, nil
+	}
+	if expectation.Status == 0 && (hop.Status < 300 || hop.Status > 399) {
+		return false, nil
+	}
+	if expectation.LocationRegex != "" {
+		re, err := regexp.Compile(expectation.LocationRegex)
+		if err != nil {
+			return false, fmt.Errorf("invalid location_regex: %w", err)
+		}
+		return re.MatchString(hop.Location), nil
+	}
+	return expectation.Location == "" || compareLocations(hop.Location, expectation.Location), nil
+}
+
+// evaluateRedirects compares the observed chain (ending with the final response) with the
+// expected hops and final URL. Every redirect must be expected: a chain that is longer or
+// shorter than expected_redirects fails.
+func (test Application) evaluateRedirects(chain []RedirectHop) []RedirectResult {
+	if !test.followsRedirects() || len(chain) == 0 {
+		return nil
+	}
+	redirects := chain[:len(chain)-1]
+	final := chain[len(chain)-1]
+
+	var results []RedirectResult
+	if len(test.ExpectedRedirects) > 0 {
+		for i := 0; i < max(len(test.ExpectedRedirects), len(redirects)); i++ {
+			result := RedirectResult{Kind: redirectHop, Hop: i + 1, Expected: "no redirect", Actual: "no redirect"}
+			if i < len(redirects) {
+				result.Actual = redirects[i].String()
+			}
+			if i < len(test.ExpectedRedirects) {
+				expectation := test.ExpectedRedirects[i]
+				result.Expected = expectation.String()
+				if i < len(redirects) {
+					ok, err := expectation.matches(redirects[i])
+					result.Ok = ok
+					if err != nil {
+						result.Actual = err.Error()
+					}
+				}
+			}
+			results = append(results, result)
+		}
+	}
+	if test.ExpectedFinalURL != "" {
+		results = append(results, RedirectResult{
+			Kind:     redirectFinalURL,
+			Ok:       compareLocations(final.URL, test.ExpectedFinalURL),
+			Expected: test.ExpectedFinalURL,
+			Actual:   final.URL,
+		})
+	}
+	return results
+}
+
+func (result RedirectResult) passed() bool {
+	return result.Ok
+}
+
+// redirectResultString formats one redirect assertion outcome.
+func redirectResultString(result RedirectResult) string {
+	if result.Kind == redirectFinalURL {
+		if result.Ok {
+			return fmt.Sprintf("Success: Final URL matched %s", result.Actual)
+		}
+		return fmt.Sprintf("Failure: Final URL %s did not match %s", result.Actual, result.Expected)
+	}
+	if result.Ok {
+		return fmt.Sprintf("Success: Redirect %d matched %s", result.Hop, result.Actual)
+	}
+	return fmt.Sprintf("Failure: Redirect %d was %s, expected %s", result.Hop, result.Actual, result.Expected)
+}
+
+// redirectChainString renders the whole observed chain, shown when a redirect assertion fails.
+func redirectChainString(chain []RedirectHop) string {
+	parts := make([]string, 0, len(chain)+1)
+	for _, hop := range chain {
+		parts = append(parts, fmt.Sprintf("%s (%d)", hop.URL, hop.Status))
+	}
+	return "Redirect chain: " + strings.Join(parts, " -> ")
+}
@@ -0,0 +1,193 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRedirectExpectationsUnmarshalYAML(t *testing.T) {
+	const config = `
+expected_redirects:
+  - status: 303
+    location: 'https://search.library.nyu.edu/discovery/fulldisplay?docid=alma990097005700107871&context=L&vid=01NYU_INST:NYU'
+  - location_regex: '^https://search\.library\.nyu\.edu/discovery/'
+expected_final_url: 'https://search.library.nyu.edu/discovery/fulldisplay?docid=alma990097005700107871&vid=01NYU_INST:NYU'
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+
+	require.Len(t, app.ExpectedRedirects, 2)
+	assert.Equal(t, 303, app.ExpectedRedirects[0].Status)
+	assert.Equal(t, `^https://search\.library\.nyu\.edu/discovery/`, app.ExpectedRedirects[1].LocationRegex)
+	assert.NotEmpty(t, app.ExpectedFinalURL)
+	assert.True(t, app.followsRedirects())
+}
+
+func TestEvaluateRedirects(t *testing.T) {
+	chain := []RedirectHop{
+		{URL: "https://bobcat.library.nyu.edu/permalink/f/ci13eu/nyu_aleph009700570", Status: 303, Location: "https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1"},
+		{URL: "https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1", Status: 302, Location: "https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en"},
+		{URL: "https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en", Status: 200},
+	}
+
+	var tests = []struct {
+		description string
+		app         Application
+		want        []RedirectResult
+	}{
+		{
+			"No expectations",
+			Application{},
+			nil,
+		},
+		{
+			"Every hop matches",
+			Application{ExpectedRedirects: []RedirectExpectation{
+				{Status: 303, Location: "https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1"},
+				{LocationRegex: `lang=en$`},
+			}},
+			[]RedirectResult{
+				{Kind: redirectHop, Hop: 1, Ok: true, Expected: "303 -> https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1", Actual: "303 -> https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1"},
+				{Kind: redirectHop, Hop: 2, Ok: true, Expected: "3xx -> /lang=en$/", Actual: "302 -> https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en"},
+			},
+		},
+		{
+			"Intermediate hop has the wrong status",
+			Application{ExpectedRedirects: []RedirectExpectation{{Status: 303}, {Status: 301}}},
+			[]RedirectResult{
+				{Kind: redirectHop, Hop: 1, Ok: true, Expected: "303", Actual: "303 -> https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1"},
+				{Kind: redirectHop, Hop: 2, Expected: "301", Actual: "302 -> https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en"},
+			},
+		},
+		{
+			"Unexpected extra hop",
+			Application{ExpectedRedirects: []RedirectExpectation{{Status: 303}}},
+			[]RedirectResult{
+				{Kind: redirectHop, Hop: 1, Ok: true, Expected: "303", Actual: "303 -> https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1"},
+				{Kind: redirectHop, Hop: 2, Expected: "no redirect", Actual: "302 -> https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en"},
+			},
+		},
+		{
+			"Missing hop",
+			Application{ExpectedRedirects: []RedirectExpectation{{Status: 303}, {Status: 302}, {Status: 301}}},
+			[]RedirectResult{
+				{Kind: redirectHop, Hop: 1, Ok: true, Expected: "303", Actual: "303 -> https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1"},
+				{Kind: redirectHop, Hop: 2, Ok: true, Expected: "302", Actual: "302 -> https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en"},
+				{Kind: redirectHop, Hop: 3, Expected: "301", Actual: "no redirect"},
+			},
+		},
+		{
+			"Invalid location regex",
+			Application{ExpectedRedirects: []RedirectExpectation{{LocationRegex: "("}, {}}},
+			[]RedirectResult{
+				{Kind: redirectHop, Hop: 1, Expected: "3xx -> /(/", Actual: "invalid location_regex: error parsing regexp: missing closing ): `(`"},
+				{Kind: redirectHop, Hop: 2, Ok: true, Expected: "3xx", Actual: "302 -> https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en"},
+			},
+		},
+		{
+			"Final URL",
+			Application{ExpectedFinalURL: "https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en"},
+			[]RedirectResult{{Kind: redirectFinalURL, Ok: true, Expected: "https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en", Actual: "https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en"}},
+		},
+		{
+			"Final URL mismatch",
+			Application{ExpectedFinalURL: "https://search.library.nyu.edu/discovery/search"},
+			[]RedirectResult{{Kind: redirectFinalURL, Expected: "https://search.library.nyu.edu/discovery/search", Actual: "https://search.library.nyu.edu/discovery/fulldisplay?docid=alma1&lang=en"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.want, test.app.evaluateRedirects(chain))
+		})
+	}
+}
+
+func TestRedirectResultString(t *testing.T) {
+	assert.Equal(t, "Success: Redirect 1 matched 303 -> https://search.library.nyu.edu/",
+		redirectResultString(RedirectResult{Kind: redirectHop, Hop: 1, Ok: true, Actual: "303 -> https://search.library.nyu.edu/"}))
+	assert.Equal(t, "Failure: Redirect 2 was 302 -> https://login.library.nyu.edu/, expected 301 -> /^https://search/",
+		redirectResultString(RedirectResult{Kind: redirectHop, Hop: 2, Expected: "301 -> /^https://search/", Actual: "302 -> https://login.library.nyu.edu/"}))
+	assert.Equal(t, "Success: Final URL matched https://search.library.nyu.edu/",
+		redirectResultString(RedirectResult{Kind: redirectFinalURL, Ok: true, Actual: "https://search.library.nyu.edu/"}))
+	assert.Equal(t, "Failure: Final URL https://login.library.nyu.edu/ did not match https://search.library.nyu.edu/",
+		redirectResultString(RedirectResult{Kind: redirectFinalURL, Expected: "https://search.library.nyu.edu/", Actual: "https://login.library.nyu.edu/"}))
+	assert.Equal(t, "Redirect chain: http://a/ (301) -> http://b/ (200)",
+		redirectChainString([]RedirectHop{{URL: "http://a/", Status: 301, Location: "http://b/"}, {URL: "http://b/", Status: 200}}))
+}
+
+func TestGetStatus_RedirectChain(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/permalink", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/discovery", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/discovery", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/discovery/fulldisplay?docid=alma1", http.StatusFound)
+	})
+	mux.HandleFunc("/discovery/fulldisplay", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "bobcat-permalink",
+		URL:                srv.URL + "/permalink",
+		ExpectedStatusCode: http.StatusSeeOther,
+		Timeout:            2 * time.Second,
+		ExpectedRedirects: []RedirectExpectation{
+			{Status: http.StatusSeeOther, Location: "/discovery"},
+			{Status: http.StatusMovedPermanently},
+		},
+		ExpectedFinalURL: srv.URL + "/discovery/fulldisplay?docid=alma1",
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk, "the first hop still matches expected_status")
+	assert.False(t, status.IsOk(), "a broken intermediate hop fails the check")
+	assert.Equal(t, []RedirectHop{
+		{URL: srv.URL + "/permalink", Status: http.StatusSeeOther, Location: srv.URL + "/discovery"},
+		{URL: srv.URL + "/discovery", Status: http.StatusFound, Location: srv.URL + "/discovery/fulldisplay?docid=alma1"},
+		{URL: srv.URL + "/discovery/fulldisplay?docid=alma1", Status: http.StatusOK},
+	}, status.RedirectChain)
+	assert.Equal(t, "Success: URL "+srv.URL+"/permalink resolved with 303\n"+
+		"Success: Redirect 1 matched 303 -> "+srv.URL+"/discovery\n"+
+		"Failure: Redirect 2 was 302 -> "+srv.URL+"/discovery/fulldisplay?docid=alma1, expected 301\n"+
+		"Success: Final URL matched "+srv.URL+"/discovery/fulldisplay?docid=alma1\n"+
+		"Redirect chain: "+srv.URL+"/permalink (303) -> "+srv.URL+"/discovery (302) -> "+srv.URL+"/discovery/fulldisplay?docid=alma1 (200)",
+		outputWithoutTimings(status))
+}
+
+func TestGetStatus_RedirectChainTooLong(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "loop",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusFound,
+		Timeout:            2 * time.Second,
+		MaxRedirects:       2,
+		ExpectedFinalURL:   srv.URL + "/done",
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.False(t, status.IsOk())
+	assert.Contains(t, status.Error, "stopped after 2 redirects")
+	assert.Equal(t, []RedirectHop{
+		{URL: srv.URL, Status: http.StatusFound, Location: srv.URL + "/loop"},
+	}, status.RedirectChain, "the hops followed before giving up are kept, the refused one is not")
+	assert.Contains(t, status.String(), "Redirect chain: "+srv.URL+" (302)\n")
+}
@@ -0,0 +1,93 @@
+package application
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// requestMethod returns the configured HTTP method in upper case, defaulting to GET.
+func (test Application) requestMethod() string {
+	if test.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(test.Method)
+}
+
+// probesWithHead reports whether the probe phase may use a HEAD request.
+// Only checks whose method is GET or HEAD are safe to probe this way; any other method
+// (e.g. POST for search APIs) is probed with the configured method itself.
+func (test Application) probesWithHead() bool {
+	method := test.requestMethod()
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// requestBody returns the configured request body, read from BodyFile when set.
+// A nil body is returned when neither Body nor BodyFile is configured.
+func (test Application) requestBody() (io.Reader, error) {
+	if test.BodyFile != "" {
+		data, err := os.ReadFile(test.BodyFile)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	}
+	if test.Body != "" {
+		return strings.NewReader(test.Body), nil
+	}
+	return nil, nil
+}
+
+// newRequest builds a request for the application's URL with the given method,
+// applying the configured headers, credentials and, for methods other than HEAD, the configured body.
+// The User-Agent defaults to the ASWA agent unless overridden in Headers.
+func newRequest(test Application, method string) (*http.Request, error) {
+	var body io.Reader
+	if method != http.MethodHead {
+		var err error
+		if body, err = test.requestBody(); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, test.URL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	for name, value := range test.Headers {
+		// net/http ignores a Host entry in Header; it has to be set on the request itself.
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+
+	if test.Auth != nil {
+		if err := test.Auth.apply(req, test.tokenClient); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// doRequest sends the request, making sure a transport error never echoes credentials
+// that were added to the request URL (e.g. an API key query parameter) and that an OAuth2
+// token the application rejected is not reused.
+func doRequest(test Application, client *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	test.Auth.dropRejectedToken(req, resp)
+	var urlErr *url.Error
+	if err != nil && errors.As(err, &urlErr) {
+		if parsed, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+			urlErr.URL = test.Auth.redactURL(parsed)
+		}
+	}
+	return resp, err
+}
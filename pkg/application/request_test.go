@@ -0,0 +1,202 @@
+package application
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequest(t *testing.T) {
+	bodyFile := filepath.Join(t.TempDir(), "query.graphql")
+	require.NoError(t, os.WriteFile(bodyFile, []byte(`{"query":"{ status }"}`), 0o600))
+
+	var tests = []struct {
+		description   string
+		app           Application
+		method        string
+		wantUserAgent string
+		wantHeaders   map[string]string
+		wantHost      string
+		wantBody      string
+		wantErr       string
+	}{
+		{
+			description:   "Default user agent, no body",
+			app:           Application{URL: "https://library.nyu.edu"},
+			method:        http.MethodGet,
+			wantUserAgent: userAgent,
+		},
+		{
+			description:   "Custom headers and user agent override",
+			app:           Application{URL: "https://library.nyu.edu", Headers: map[string]string{"Accept": "application/json", "User-Agent": "Mozilla/5.0"}},
+			method:        http.MethodGet,
+			wantUserAgent: "Mozilla/5.0",
+			wantHeaders:   map[string]string{"Accept": "application/json"},
+		},
+		{
+			description:   "Host header is set on the request",
+			app:           Application{URL: "https://127.0.0.1", Headers: map[string]string{"host": "library.nyu.edu"}},
+			method:        http.MethodGet,
+			wantUserAgent: userAgent,
+			wantHost:      "library.nyu.edu",
+		},
+		{
+			description:   "Raw body",
+			app:           Application{URL: "https://library.nyu.edu", Method: "post", Body: "q=hamlet"},
+			method:        http.MethodPost,
+			wantUserAgent: userAgent,
+			wantBody:      "q=hamlet",
+		},
+		{
+			description:   "Body from file",
+			app:           Application{URL: "https://library.nyu.edu", Method: http.MethodPost, BodyFile: bodyFile},
+			method:        http.MethodPost,
+			wantUserAgent: userAgent,
+			wantBody:      `{"query":"{ status }"}`,
+		},
+		{
+			description:   "HEAD never carries a body",
+			app:           Application{URL: "https://library.nyu.edu", Body: "q=hamlet"},
+			method:        http.MethodHead,
+			wantUserAgent: userAgent,
+		},
+		{
+			description: "Missing body file",
+			app:         Application{URL: "https://library.nyu.edu", Method: http.MethodPost, BodyFile: filepath.Join(t.TempDir(), "missing.json")},
+			method:      http.MethodPost,
+			wantErr:     "no such file or directory",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req, err := newRequest(test.app, test.method)
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, test.method, req.Method)
+			assert.Equal(t, test.wantUserAgent, req.Header.Get("User-Agent"))
+			for name, value := range test.wantHeaders {
+				assert.Equal(t, value, req.Header.Get(name))
+			}
+			if test.wantHost != "" {
+				assert.Equal(t, test.wantHost, req.Host)
+			}
+
+			var body []byte
+			if req.Body != nil {
+				body, err = io.ReadAll(req.Body)
+				require.NoError(t, err)
+			}
+			assert.Equal(t, test.wantBody, string(body))
+		})
+	}
+}
+
+func TestGetStatus_PostWithHeadersAndBody(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method)
+		mu.Unlock()
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Accept") != "application/json" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"echo":%q}`, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "search-api",
+		URL:                srv.URL + "/search",
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		Method:             http.MethodPost,
+		Headers:            map[string]string{"Accept": "application/json"},
+		Body:               "hamlet",
+		ExpectedContent:    ContentAssertions{{Value: `"echo":"hamlet"`}},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk, "POST probe should resolve with 200")
+	assert.True(t, status.StatusContentOk, "POST response body should contain the echoed request body")
+	assert.Equal(t, []string{http.MethodPost}, methods, "a POST check must be sent once, never probed with HEAD or GET")
+}
+
+func TestGetStatus_PostIsNotSentAgainToFollowRedirects(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		http.Redirect(w, r, "/results", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/results", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		_, _ = fmt.Fprint(w, "3 results")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "search-form",
+		URL:                srv.URL + "/search",
+		ExpectedStatusCode: http.StatusSeeOther,
+		Timeout:            2 * time.Second,
+		Method:             http.MethodPost,
+		Body:               "q=hamlet",
+		ExpectedContent:    ContentAssertions{{Value: "3 results"}},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.IsOk(), status.String())
+	assert.Equal(t, []string{"POST /search", "GET /results"}, requests, "the redirect is followed from the probe's response")
+}
+
+func TestProbesWithHead(t *testing.T) {
+	var tests = []struct {
+		method string
+		want   bool
+	}{
+		{"", true},
+		{"get", true},
+		{http.MethodHead, true},
+		{http.MethodPost, false},
+		{"put", false},
+	}
+
+	for _, test := range tests {
+		t.Run("method "+test.method, func(t *testing.T) {
+			assert.Equal(t, test.want, Application{Method: test.method}.probesWithHead())
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package application
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRetryBackoff is the wait before the first retry when retry_backoff is not set.
+	defaultRetryBackoff = 1 * time.Second
+	// maxRetryBackoff caps the exponential backoff between attempts.
+	maxRetryBackoff = 30 * time.Second
+)
+
+// Attempt records the outcome of a single try of a synthetic test.
+type Attempt struct {
+	Number           int
+	Ok               bool
+	ActualStatusCode int
+	Error            string
+}
+
+// GetStatus runs the synthetic test, retrying failed attempts with exponential backoff.
+//
+// Behavior summary:
+//   - Stops at the first successful attempt; earlier failures are kept in Attempts
+//     so a flaky check can be told apart from one that is down.
+//   - Reports a failure once every attempt, the first and Retries more, has failed.
+//   - With no retry settings, this is a single attempt, as before.
+func (test Application) GetStatus() *AppCheckStatus {
+	maxAttempts := test.maxAttempts()
+
+	var status *AppCheckStatus
+	var attempts []Attempt
+
+	for number := 1; number <= maxAttempts; number++ {
+		if number > 1 {
+			delay := test.retryDelay(number - 1)
+			if DebugMode {
+				log.Printf("[retry] url=%s attempt=%d/%d delay=%s", test.URL, number, maxAttempts, delay)
+			}
+			time.Sleep(delay)
+		}
+
+		status = test.getStatusOnce()
+		attempts = append(attempts, newAttempt(number, status))
+		if status.IsOk() {
+			break
+		}
+	}
+
+	status.Attempts = attempts
+	return status
+}
+
+// maxAttempts returns how many times the test may run: once plus the configured retries.
+func (test Application) maxAttempts() int {
+	return 1 + max(test.Retries, 0)
+}
+
+// retryDelay returns the wait before the given retry (1-based), doubling each time.
+func (test Application) retryDelay(retry int) time.Duration {
+	delay := test.RetryBackoff
+	if delay <= 0 {
+		delay = defaultRetryBackoff
+	}
+	for i := 1; i < retry && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryBackoff)
+}
+
+func newAttempt(number int, status *AppCheckStatus) Attempt {
+	return Attempt{
+		Number:           number,
+		Ok:               status.IsOk(),
+		ActualStatusCode: status.ActualStatusCode,
+		Error:            status.Error,
+	}
+}
+
+// String describes a single attempt, e.g. "attempt 1: resolved with 500".
+func (attempt Attempt) String() string {
+	switch {
+	case attempt.Ok:
+		return fmt.Sprintf("attempt %d: ok", attempt.Number)
+	case attempt.Error != "":
+		return fmt.Sprintf("attempt %d: request error: %s", attempt.Number, attempt.Error)
+	default:
+		return fmt.Sprintf("attempt %d: resolved with %d", attempt.Number, attempt.ActualStatusCode)
+	}
+}
+
+// attemptsString summarizes the attempt history of a test that needed more than one attempt.
+// A test that eventually passed is reported as flaky rather than failed.
+func attemptsString(results AppCheckStatus) string {
+	var history []string
+	for _, attempt := range results.Attempts {
+		history = append(history, attempt.String())
+	}
+
+	if results.IsOk() {
+		return fmt.Sprintf("Flaky: %s succeeded on attempt %d of %d (%s)",
+			results.Application.label(), len(results.Attempts), results.Application.maxAttempts(), strings.Join(history, "; "))
+	}
+	return fmt.Sprintf("Failure: %s failed %d consecutive attempts (%s)",
+		results.Application.label(), len(results.Attempts), strings.Join(history, "; "))
+}
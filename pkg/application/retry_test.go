@@ -0,0 +1,126 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer returns a server that answers with failStatus for the first failures
+// requests and 200 afterwards, along with a counter of requests received.
+func newFlakyServer(failures int32, failStatus int) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(failStatus)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	return srv, &requests
+}
+
+func TestGetStatus_Retries(t *testing.T) {
+	var tests = []struct {
+		description  string
+		failures     int32
+		retries      int
+		wantOk       bool
+		wantAttempts int
+	}{
+		{"No retries, healthy", 0, 0, true, 1},
+		{"No retries, single blip fails", 1, 0, false, 1},
+		{"Retry recovers from a blip", 1, 2, true, 2},
+		{"Retry recovers on last attempt", 2, 2, true, 3},
+		{"Down for every attempt", 5, 2, false, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			srv, requests := newFlakyServer(test.failures, http.StatusInternalServerError)
+			t.Cleanup(srv.Close)
+
+			app := &Application{
+				Name:               "flaky",
+				URL:                srv.URL,
+				ExpectedStatusCode: http.StatusOK,
+				Timeout:            time.Second,
+				Retries:            test.retries,
+				RetryBackoff:       time.Millisecond,
+			}
+
+			status := app.GetStatus()
+
+			require.NotNil(t, status)
+			assert.Equal(t, test.wantOk, status.IsOk())
+			assert.Len(t, status.Attempts, test.wantAttempts)
+			assert.Equal(t, int32(test.wantAttempts), requests.Load(), "one HEAD probe per attempt")
+			for i, attempt := range status.Attempts {
+				assert.Equal(t, i+1, attempt.Number)
+				assert.Equal(t, i == len(status.Attempts)-1 && test.wantOk, attempt.Ok)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	var tests = []struct {
+		description string
+		backoff     time.Duration
+		retry       int
+		want        time.Duration
+	}{
+		{"Default backoff for first retry", 0, 1, defaultRetryBackoff},
+		{"Configured backoff for first retry", 200 * time.Millisecond, 1, 200 * time.Millisecond},
+		{"Backoff doubles", 200 * time.Millisecond, 3, 800 * time.Millisecond},
+		{"Backoff is capped", 10 * time.Second, 5, maxRetryBackoff},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.want, Application{RetryBackoff: test.backoff}.retryDelay(test.retry))
+		})
+	}
+}
+
+func TestStringWithAttempts(t *testing.T) {
+	app := &Application{URL: "https://ill.library.nyu.edu", ExpectedStatusCode: http.StatusOK, Retries: 2}
+
+	var tests = []struct {
+		description    string
+		appStatus      *AppCheckStatus
+		expectedOutput string
+	}{
+		{
+			description: "Single attempt has no history line",
+			appStatus: &AppCheckStatus{Application: app, StatusOk: true, StatusContentOk: true, StatusCSPOk: true, ActualStatusCode: 200,
+				Attempts: []Attempt{{Number: 1, Ok: true, ActualStatusCode: 200}}},
+			expectedOutput: "Success: URL https://ill.library.nyu.edu resolved with 200",
+		},
+		{
+			description: "Flaky success",
+			appStatus: &AppCheckStatus{Application: app, StatusOk: true, StatusContentOk: true, StatusCSPOk: true, ActualStatusCode: 200,
+				Attempts: []Attempt{{Number: 1, ActualStatusCode: 503}, {Number: 2, Error: "context deadline exceeded"}, {Number: 3, Ok: true, ActualStatusCode: 200}}},
+			expectedOutput: "Success: URL https://ill.library.nyu.edu resolved with 200\n" +
+				"Flaky: URL https://ill.library.nyu.edu succeeded on attempt 3 of 3 (attempt 1: resolved with 503; attempt 2: request error: context deadline exceeded; attempt 3: ok)",
+		},
+		{
+			description: "Failure after every attempt",
+			appStatus: &AppCheckStatus{Application: app, StatusOk: false, StatusContentOk: true, StatusCSPOk: true, ActualStatusCode: 503,
+				Attempts: []Attempt{{Number: 1, ActualStatusCode: 503}, {Number: 2, ActualStatusCode: 503}, {Number: 3, ActualStatusCode: 503}}},
+			expectedOutput: "Failure: URL https://ill.library.nyu.edu resolved with 503, expected 200\n" +
+				"Failure: URL https://ill.library.nyu.edu failed 3 consecutive attempts (attempt 1: resolved with 503; attempt 2: resolved with 503; attempt 3: resolved with 503)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.expectedOutput, test.appStatus.String())
+		})
+	}
+}
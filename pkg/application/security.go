@@ -0,0 +1,261 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Security profiles for security_profile. Both run the same audit; strict also fails
+// the items baseline only warns about.
+const (
+	SecurityBaseline = "baseline"
+	SecurityStrict   = "strict"
+)
+
+// Grades of a security audit item. Only securityFail fails the check.
+const (
+	securityPass = "pass"
+	securityWarn = "warn"
+	securityFail = "fail"
+)
+
+// minHSTSMaxAge is the shortest Strict-Transport-Security max-age accepted: 180 days.
+const minHSTSMaxAge = 180 * 24 * 60 * 60
+
+// SecurityResult is the graded outcome of one item of the security audit.
+type SecurityResult struct {
+	Item   string
+	Grade  string
+	Detail string
+}
+
+// auditSecurity runs the security_profile audit against the final response, plus a
+// plain-HTTP request to check that the site redirects to HTTPS.
+func (test Application) auditSecurity(client *http.Client, finalURL string, header http.Header) []SecurityResult {
+	if test.SecurityProfile == "" {
+		return nil
+	}
+	if test.SecurityProfile != SecurityBaseline && test.SecurityProfile != SecurityStrict {
+		return []SecurityResult{{Item: "Profile", Grade: securityFail, Detail: fmt.Sprintf("unknown security_profile %q", test.SecurityProfile)}}
+	}
+
+	results := evaluateSecurityHeaders(finalURL, header)
+	results = append(results, checkHTTPSRedirect(client, insecureURL(test.URL)))
+	if test.SecurityProfile == SecurityStrict {
+		for i := range results {
+			if results[i].Grade == securityWarn {
+				results[i].Grade = securityFail
+			}
+		}
+	}
+	return results
+}
+
+// evaluateSecurityHeaders grades the security headers and cookies of the final response.
+func evaluateSecurityHeaders(finalURL string, header http.Header) []SecurityResult {
+	return []SecurityResult{
+		evaluateHSTS(finalURL, header.Get("Strict-Transport-Security")),
+		evaluateCSPPresence(header),
+		evaluateContentTypeOptions(header.Get("X-Content-Type-Options")),
+		evaluateReferrerPolicy(header.Get("Referrer-Policy")),
+		evaluateCookies(header.Values("Set-Cookie")),
+	}
+}
+
+func evaluateHSTS(finalURL, value string) SecurityResult {
+	result := SecurityResult{Item: "Strict-Transport-Security"}
+	if u, err := url.Parse(finalURL); err == nil && u.Scheme == "http" {
+		result.Grade, result.Detail = securityFail, "final URL is not HTTPS"
+		return result
+	}
+	if value == "" {
+		result.Grade, result.Detail = securityFail, "missing"
+		return result
+	}
+
+	maxAge := -1
+	includeSubDomains := false
+	for _, directive := range strings.Split(value, ";") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(strings.TrimSpace(arg), `"`)); err == nil {
+				maxAge = seconds
+			}
+		case "includesubdomains":
+			includeSubDomains = true
+		}
+	}
+
+	switch {
+	case maxAge < 0:
+		result.Grade, result.Detail = securityFail, fmt.Sprintf("no valid max-age in %q", value)
+	case maxAge < minHSTSMaxAge:
+		result.Grade, result.Detail = securityFail, fmt.Sprintf("max-age=%d is under 180 days", maxAge)
+	case !includeSubDomains:
+		result.Grade, result.Detail = securityWarn, fmt.Sprintf("max-age=%d without includeSubDomains", maxAge)
+	default:
+		result.Grade, result.Detail = securityPass, fmt.Sprintf("max-age=%d; includeSubDomains", maxAge)
+	}
+	return result
+}
+
+func evaluateCSPPresence(header http.Header) SecurityResult {
+	result := SecurityResult{Item: "Content-Security-Policy"}
+	switch {
+	case header.Get("Content-Security-Policy") != "":
+		result.Grade, result.Detail = securityPass, "present"
+	case header.Get("Content-Security-Policy-Report-Only") != "":
+		result.Grade, result.Detail = securityWarn, "report-only"
+	default:
+		result.Grade, result.Detail = securityFail, "missing"
+	}
+	return result
+}
+
+func evaluateContentTypeOptions(value string) SecurityResult {
+	result := SecurityResult{Item: "X-Content-Type-Options"}
+	switch {
+	case value == "":
+		result.Grade, result.Detail = securityFail, "missing"
+	case strings.EqualFold(strings.TrimSpace(value), "nosniff"):
+		result.Grade, result.Detail = securityPass, "nosniff"
+	default:
+		result.Grade, result.Detail = securityFail, fmt.Sprintf("%q, expected nosniff", value)
+	}
+	return result
+}
+
+// evaluateReferrerPolicy grades the policy browsers apply, the last one listed.
+func evaluateReferrerPolicy(value string) SecurityResult {
+	result := SecurityResult{Item: "Referrer-Policy"}
+	policies := strings.Split(value, ",")
+	policy := strings.ToLower(strings.TrimSpace(policies[len(policies)-1]))
+	switch policy {
+	case "":
+		result.Grade, result.Detail = securityWarn, "missing"
+	case "unsafe-url":
+		result.Grade, result.Detail = securityFail, policy
+	case "no-referrer-when-downgrade":
+		result.Grade, result.Detail = securityWarn, policy
+	default:
+		result.Grade, result.Detail = securityPass, policy
+	}
+	return result
+}
+
+// evaluateCookies requires Secure on every cookie, and warns about cookies without
+// HttpOnly or an explicit SameSite.
+func evaluateCookies(setCookies []string) SecurityResult {
+	result := SecurityResult{Item: "Cookies", Grade: securityPass}
+	if len(setCookies) == 0 {
+		result.Detail = "none set"
+		return result
+	}
+
+	var problems []string
+	for _, line := range setCookies {
+		cookie, err := http.ParseSetCookie(line)
+		if err != nil {
+			continue
+		}
+		var missing []string
+		if !cookie.Secure {
+			missing = append(missing, "Secure")
+			result.Grade = securityFail
+		}
+		if !cookie.HttpOnly {
+			missing = append(missing, "HttpOnly")
+		}
+		if cookie.SameSite == 0 || cookie.SameSite == http.SameSiteDefaultMode {
+			missing = append(missing, "SameSite")
+		}
+		if len(missing) > 0 {
+			if result.Grade == securityPass {
+				result.Grade = securityWarn
+			}
+			problems = append(problems, fmt.Sprintf("%s missing %s", cookie.Name, strings.Join(missing, ", ")))
+		}
+	}
+	if len(problems) == 0 {
+		result.Detail = fmt.Sprintf("%d set with Secure, HttpOnly and SameSite", len(setCookies))
+	} else {
+		result.Detail = strings.Join(problems, "; ")
+	}
+	return result
+}
+
+// insecureURL is the plain-HTTP form of rawURL, on the default port.
+func insecureURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.Scheme == "https" {
+		u.Scheme = "http"
+		if u.Port() == "443" {
+			u.Host = u.Hostname()
+		}
+	}
+	return u.String()
+}
+
+// checkHTTPSRedirect requests httpURL without following redirects and expects a redirect
+// to HTTPS. Credentials and configured headers are deliberately not sent over plain HTTP.
+// A site that does not answer on HTTP at all is only a warning.
+func checkHTTPSRedirect(client *http.Client, httpURL string) SecurityResult {
+	result := SecurityResult{Item: "HTTPS redirect"}
+
+	req, err := http.NewRequest(http.MethodGet, httpURL, nil)
+	if err != nil {
+		result.Grade, result.Detail = securityFail, err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	noFollow := *client
+	noFollow.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := noFollow.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		result.Grade, result.Detail = securityWarn, fmt.Sprintf("%s not reachable: %v", httpURL, err)
+		return result
+	}
+	defer closeResponseBody(resp.Body)
+
+	location, _ := resp.Location()
+	switch {
+	case resp.StatusCode < 300 || resp.StatusCode > 399:
+		result.Grade, result.Detail = securityFail, fmt.Sprintf("%s served %d over HTTP", httpURL, resp.StatusCode)
+	case location == nil || location.Scheme != "https":
+		result.Grade, result.Detail = securityFail, fmt.Sprintf("%s redirected to %s", httpURL, resp.Header.Get("Location"))
+	default:
+		result.Grade, result.Detail = securityPass, fmt.Sprintf("%s -> %s", httpURL, location)
+	}
+	return result
+}
+
+// passed is false only for failures; warnings do not fail the check.
+func (result SecurityResult) passed() bool {
+	return result.Grade != securityFail
+}
+
+// securityResultString formats one audit item. Warnings are reported as "Warning" so that
+// they do not count as failures.
+func securityResultString(result SecurityResult) string {
+	prefix := "Success"
+	switch result.Grade {
+	case securityWarn:
+		prefix = "Warning"
+	case securityFail:
+		prefix = "Failure"
+	}
+	return fmt.Sprintf("%s: Security %s: %s", prefix, result.Item, result.Detail)
+}
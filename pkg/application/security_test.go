@@ -0,0 +1,203 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateSecurityHeaders(t *testing.T) {
+	var tests = []struct {
+		description string
+		finalURL    string
+		header      http.Header
+		want        []SecurityResult
+	}{
+		{
+			"Hardened site",
+			"https://library.nyu.edu/",
+			http.Header{
+				"Strict-Transport-Security": {"max-age=31536000; includeSubDomains; preload"},
+				"Content-Security-Policy":   {"default-src 'self'"},
+				"X-Content-Type-Options":    {"nosniff"},
+				"Referrer-Policy":           {"no-referrer, strict-origin-when-cross-origin"},
+				"Set-Cookie":                {"_session=abc; Path=/; Secure; HttpOnly; SameSite=Lax"},
+			},
+			[]SecurityResult{
+				{Item: "Strict-Transport-Security", Grade: securityPass, Detail: "max-age=31536000; includeSubDomains"},
+				{Item: "Content-Security-Policy", Grade: securityPass, Detail: "present"},
+				{Item: "X-Content-Type-Options", Grade: securityPass, Detail: "nosniff"},
+				{Item: "Referrer-Policy", Grade: securityPass, Detail: "strict-origin-when-cross-origin"},
+				{Item: "Cookies", Grade: securityPass, Detail: "1 set with Secure, HttpOnly and SameSite"},
+			},
+		},
+		{
+			"Nothing set",
+			"https://library.nyu.edu/",
+			http.Header{},
+			[]SecurityResult{
+				{Item: "Strict-Transport-Security", Grade: securityFail, Detail: "missing"},
+				{Item: "Content-Security-Policy", Grade: securityFail, Detail: "missing"},
+				{Item: "X-Content-Type-Options", Grade: securityFail, Detail: "missing"},
+				{Item: "Referrer-Policy", Grade: securityWarn, Detail: "missing"},
+				{Item: "Cookies", Grade: securityPass, Detail: "none set"},
+			},
+		},
+		{
+			"Weak settings",
+			"https://library.nyu.edu/",
+			http.Header{
+				"Strict-Transport-Security":           {"max-age=31536000"},
+				"Content-Security-Policy-Report-Only": {"default-src 'self'"},
+				"X-Content-Type-Options":              {"sniff"},
+				"Referrer-Policy":                     {"no-referrer-when-downgrade"},
+				"Set-Cookie":                          {"_ga=GA1.2; Path=/; Secure", "JSESSIONID=1; Path=/; HttpOnly; SameSite=Strict"},
+			},
+			[]SecurityResult{
+				{Item: "Strict-Transport-Security", Grade: securityWarn, Detail: "max-age=31536000 without includeSubDomains"},
+				{Item: "Content-Security-Policy", Grade: securityWarn, Detail: "report-only"},
+				{Item: "X-Content-Type-Options", Grade: securityFail, Detail: `"sniff", expected nosniff`},
+				{Item: "Referrer-Policy", Grade: securityWarn, Detail: "no-referrer-when-downgrade"},
+				{Item: "Cookies", Grade: securityFail, Detail: "_ga missing HttpOnly, SameSite; JSESSIONID missing Secure"},
+			},
+		},
+		{
+			"Short HSTS over HTTP",
+			"http://library.nyu.edu/",
+			http.Header{"Strict-Transport-Security": {"max-age=300; includeSubDomains"}, "Referrer-Policy": {"unsafe-url"}},
+			[]SecurityResult{
+				{Item: "Strict-Transport-Security", Grade: securityFail, Detail: "final URL is not HTTPS"},
+				{Item: "Content-Security-Policy", Grade: securityFail, Detail: "missing"},
+				{Item: "X-Content-Type-Options", Grade: securityFail, Detail: "missing"},
+				{Item: "Referrer-Policy", Grade: securityFail, Detail: "unsafe-url"},
+				{Item: "Cookies", Grade: securityPass, Detail: "none set"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.want, evaluateSecurityHeaders(test.finalURL, test.header))
+		})
+	}
+}
+
+func TestEvaluateHSTSMaxAge(t *testing.T) {
+	assert.Equal(t, SecurityResult{Item: "Strict-Transport-Security", Grade: securityFail, Detail: "max-age=300 is under 180 days"},
+		evaluateHSTS("https://library.nyu.edu/", "max-age=300; includeSubDomains"))
+	assert.Equal(t, SecurityResult{Item: "Strict-Transport-Security", Grade: securityFail, Detail: `no valid max-age in "includeSubDomains"`},
+		evaluateHSTS("https://library.nyu.edu/", "includeSubDomains"))
+	assert.Equal(t, securityPass, evaluateHSTS("https://library.nyu.edu/", `MAX-AGE="63072000"; includesubdomains`).Grade)
+}
+
+func TestInsecureURL(t *testing.T) {
+	assert.Equal(t, "http://library.nyu.edu/guides?q=1", insecureURL("https://library.nyu.edu/guides?q=1"))
+	assert.Equal(t, "http://library.nyu.edu/", insecureURL("https://library.nyu.edu:443/"))
+	assert.Equal(t, "http://library.nyu.edu/", insecureURL("http://library.nyu.edu/"))
+}
+
+func TestCheckHTTPSRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/secure", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://library.nyu.edu/", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/insecure", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/plain", http.StatusFound)
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	assert.Equal(t, SecurityResult{Item: "HTTPS redirect", Grade: securityPass, Detail: srv.URL + "/secure -> https://library.nyu.edu/"},
+		checkHTTPSRedirect(client, srv.URL+"/secure"))
+	assert.Equal(t, SecurityResult{Item: "HTTPS redirect", Grade: securityFail, Detail: srv.URL + "/insecure redirected to /plain"},
+		checkHTTPSRedirect(client, srv.URL+"/insecure"))
+	assert.Equal(t, SecurityResult{Item: "HTTPS redirect", Grade: securityFail, Detail: srv.URL + "/plain served 200 over HTTP"},
+		checkHTTPSRedirect(client, srv.URL+"/plain"))
+
+	closed := httptest.NewServer(mux)
+	closed.Close()
+	result := checkHTTPSRedirect(client, closed.URL)
+	assert.Equal(t, securityWarn, result.Grade, "a site that does not serve HTTP at all is only a warning")
+	assert.Contains(t, result.Detail, "not reachable")
+}
+
+func TestAuditSecurity_Profiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://library.nyu.edu/", http.StatusMovedPermanently)
+	}))
+	t.Cleanup(srv.Close)
+	client := &http.Client{Timeout: 2 * time.Second}
+	header := http.Header{
+		"Strict-Transport-Security": {"max-age=31536000"},
+		"Content-Security-Policy":   {"default-src 'self'"},
+		"X-Content-Type-Options":    {"nosniff"},
+		"Referrer-Policy":           {"strict-origin"},
+	}
+
+	baseline := Application{URL: srv.URL, SecurityProfile: SecurityBaseline}.auditSecurity(client, "https://library.nyu.edu/", header)
+	require.Len(t, baseline, 6)
+	assert.Equal(t, securityWarn, baseline[0].Grade)
+	assert.True(t, allOk(baseline), "warnings do not fail the baseline profile")
+
+	strict := Application{URL: srv.URL, SecurityProfile: SecurityStrict}.auditSecurity(client, "https://library.nyu.edu/", header)
+	require.Len(t, strict, 6)
+	assert.Equal(t, securityFail, strict[0].Grade)
+	assert.False(t, allOk(strict), "the strict profile fails on warnings")
+
+	assert.Nil(t, Application{URL: srv.URL}.auditSecurity(client, "https://library.nyu.edu/", header))
+	assert.Equal(t, []SecurityResult{{Item: "Profile", Grade: securityFail, Detail: `unknown security_profile "paranoid"`}},
+		Application{URL: srv.URL, SecurityProfile: "paranoid"}.auditSecurity(client, "https://library.nyu.edu/", header))
+}
+
+func TestSecurityResultString(t *testing.T) {
+	assert.Equal(t, "Success: Security X-Content-Type-Options: nosniff",
+		securityResultString(SecurityResult{Item: "X-Content-Type-Options", Grade: securityPass, Detail: "nosniff"}))
+	assert.Equal(t, "Warning: Security Referrer-Policy: missing",
+		securityResultString(SecurityResult{Item: "Referrer-Policy", Grade: securityWarn, Detail: "missing"}))
+	assert.Equal(t, "Failure: Security Content-Security-Policy: missing",
+		securityResultString(SecurityResult{Item: "Content-Security-Policy", Grade: securityFail, Detail: "missing"}))
+}
+
+func TestGetStatus_SecurityProfile(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "library",
+		URL:                srv.URL + "/",
+		ExpectedStatusCode: http.StatusFound,
+		Timeout:            2 * time.Second,
+		SecurityProfile:    SecurityBaseline,
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk)
+	assert.False(t, status.IsOk(), "a failing audit item fails the check")
+	assert.Equal(t, "Success: URL "+srv.URL+"/ resolved with 302\n"+
+		"Failure: Security Strict-Transport-Security: final URL is not HTTPS\n"+
+		"Failure: Security Content-Security-Policy: missing\n"+
+		"Success: Security X-Content-Type-Options: nosniff\n"+
+		"Success: Security Referrer-Policy: strict-origin-when-cross-origin\n"+
+		"Success: Security Cookies: none set\n"+
+		"Failure: Security HTTPS redirect: "+srv.URL+"/ redirected to /home",
+		outputWithoutTimings(status), "the audit runs against the final response")
+}
@@ -0,0 +1,436 @@
+package application
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultStepStatusCode is the status a step expects when expected_status is not set.
+const defaultStepStatusCode = http.StatusOK
+
+// formContentType is the Content-Type of URL-encoded form bodies.
+const formContentType = "application/x-www-form-urlencoded"
+
+// referencePattern matches ${name} references to values extracted by earlier steps, and
+// ${secret:env:NAME} or ${secret:file:path} references to secrets.
+var referencePattern = regexp.MustCompile(`\$\{(?:secret:(env|file):([^}]+)|(\w+))\}`)
+
+// Step is one request of a multi-step check. Steps run in order and share a cookie jar;
+// ${name} in url, headers, body and body_form is replaced with a value extracted by an earlier
+// step, URL-encoded in the url and in form bodies. Secrets can be referenced in headers, body
+// and body_form, which are never printed, as ${secret:env:NAME} or ${secret:file:path}.
+// Redirects are followed unless follow_redirects is false, and the assertions apply to the
+// final response. The check's timeout, auth and tls settings apply to every step.
+type Step struct {
+	Name               string                     `yaml:"name"`
+	URL                string                     `yaml:"url"`
+	Method             string                     `yaml:"method"`
+	Headers            map[string]string          `yaml:"headers"`
+	Body               string                     `yaml:"body"`
+	BodyForm           map[string]string          `yaml:"body_form"`
+	FollowRedirects    *bool                      `yaml:"follow_redirects"`
+	ExpectedStatusCode int                        `yaml:"expected_status"`
+	ExpectedLocation   string                     `yaml:"expected_location"`
+	ExpectedContent    ContentAssertions          `yaml:"expected_content"`
+	ExpectedJSON       []JSONAssertion            `yaml:"expected_json"`
+	ExpectedHTML       []HTMLAssertion            `yaml:"expected_html"`
+	ExpectedXML        []XMLAssertion             `yaml:"expected_xml"`
+	ExpectedHeaders    map[string]HeaderAssertion `yaml:"expected_headers"`
+	Extract            map[string]Extraction      `yaml:"extract"`
+}
+
+// Extraction takes one value from a step's response: the first match of a Go regular
+// expression in the body (its first group, if it has one), the first value selected by a
+// JSONPath, or a response header.
+type Extraction struct {
+	Regex  string `yaml:"regex"`
+	JSON   string `yaml:"json"`
+	Header string `yaml:"header"`
+}
+
+// StepResult is the outcome of one step. Steps after a failed step are skipped.
+type StepResult struct {
+	Number         int
+	Name           string
+	Method         string
+	URL            string
+	Skipped        bool
+	StatusCode     int
+	StatusOk       bool
+	Location       string
+	ContentResults []ContentResult
+	JSONResults    []JSONResult
+	HTMLResults    []HTMLResult
+	XMLResults     []XMLResult
+	HeaderResults  []HeaderResult
+	Err            string
+}
+
+// hasSteps reports whether the application is a multi-step check.
+func (test Application) hasSteps() bool {
+	return len(test.Steps) > 0
+}
+
+// Ok reports whether the step ran, resolved with the expected status and passed every assertion.
+func (result StepResult) Ok() bool {
+	if result.Skipped || result.Err != "" || !result.StatusOk {
+		return false
+	}
+	return allOk(result.ContentResults) && allOk(result.JSONResults) && allOk(result.HTMLResults) &&
+		allOk(result.XMLResults) && allOk(result.HeaderResults)
+}
+
+func (result StepResult) passed() bool {
+	return result.Ok()
+}
+
+// getStepsStatus runs the steps in order with a shared cookie jar, stopping at the first
+// failed step. max_response_time and warn_response_time apply to the whole transaction.
+func (test Application) getStepsStatus() *AppCheckStatus {
+	status := &AppCheckStatus{Application: &test, StatusContentOk: true, StatusCSPOk: true}
+
+	client, err := createClient(test)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer closeIdleConnections(client)
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	client.Jar = jar
+
+	variables := map[string]string{}
+	start := time.Now()
+	failed := false
+	for i, step := range test.Steps {
+		if failed {
+			status.Steps = append(status.Steps, StepResult{Number: i + 1, Name: step.Name, Skipped: true})
+			continue
+		}
+		result := test.runStep(client, i+1, step, variables)
+		if !result.Ok() {
+			failed = true
+			if result.Err != "" {
+				log.Printf("[%s] Step %d error: %s", test.Name, result.Number, result.Err)
+				status.Error = fmt.Sprintf("step %d: %s", result.Number, result.Err)
+			}
+		}
+		status.Steps = append(status.Steps, result)
+	}
+	elapsed := time.Since(start)
+
+	status.StatusOk = !failed
+	status.Timings = Timings{Total: elapsed}
+	status.ResponseTimeExceeded, status.ResponseTimeWarning = test.evaluateResponseTime(elapsed)
+	return status
+}
+
+// runStep sends one step's request and evaluates its assertions and extractions.
+func (test Application) runStep(client *http.Client, number int, step Step, variables map[string]string) StepResult {
+	result := StepResult{Number: number, Name: step.Name, Method: Application{Method: step.Method}.requestMethod(), URL: step.URL}
+
+	// The step's request is built like the check's own, so auth and default headers apply.
+	request, err := test.stepRequest(step, variables)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	result.URL = request.URL
+	req, err := newRequest(request, request.requestMethod())
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	result.URL = test.Auth.redactURL(req.URL)
+
+	stepClient := *client
+	if step.FollowRedirects == nil || *step.FollowRedirects {
+		maxRedirects := test.redirectLimit()
+		stepClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			test.Auth.stripOnCrossHostRedirect(req, via)
+			return nil
+		}
+	}
+
+	resp, err := doRequest(test, &stepClient, req)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	defer closeResponseBody(resp.Body)
+
+	buf := new(bytes.Buffer)
+	if _, err := io.Copy(buf, io.LimitReader(resp.Body, maxResponseBodyBytes)); err != nil {
+		result.Err = fmt.Sprintf("reading response: %v", err)
+		return result
+	}
+	body := buf.String()
+
+	result.StatusCode = resp.StatusCode
+	result.Location = resp.Header.Get("Location")
+	result.StatusOk = compareStatusCodes(resp.StatusCode, step.expectedStatusCode()) &&
+		(step.ExpectedLocation == "" || compareLocations(result.Location, step.ExpectedLocation))
+
+	_, _, result.ContentResults = evaluateContent(body, step.ExpectedContent)
+	_, result.JSONResults = evaluateJSON(body, step.ExpectedJSON)
+	_, result.HTMLResults = evaluateHTML(body, step.ExpectedHTML)
+	_, result.XMLResults = evaluateXML(body, step.ExpectedXML, test.XMLNamespaces)
+	result.HeaderResults = evaluateHeaders(resp.Header, step.ExpectedHeaders)
+
+	if result.Ok() {
+		for name, extraction := range step.Extract {
+			value, err := extraction.extract(body, resp.Header)
+			if err != nil {
+				result.Err = fmt.Sprintf("could not extract %s: %v", name, err)
+				return result
+			}
+			variables[name] = value
+		}
+	}
+	return result
+}
+
+// stepRequest returns the check with the step's request in place of its own, with references
+// to extracted values and secrets replaced.
+func (test Application) stepRequest(step Step, variables map[string]string) (Application, error) {
+	expander := stepExpander{variables: variables}
+	request := test
+	request.Method = step.Method
+	request.BodyFile = ""
+
+	// Only values are escaped: the path and the query of the url each in their own way.
+	path, query, hasQuery := strings.Cut(step.URL, "?")
+	request.URL = expander.expand(path, url.PathEscape, false)
+	if hasQuery {
+		request.URL += "?" + expander.expand(query, url.QueryEscape, false)
+	}
+
+	request.Headers = map[string]string{}
+	for name, value := range test.Headers {
+		request.Headers[name] = value
+	}
+	for name, value := range step.Headers {
+		request.Headers[name] = expander.expand(value, nil, true)
+	}
+
+	switch {
+	case len(step.BodyForm) > 0:
+		form := url.Values{}
+		for name, value := range step.BodyForm {
+			form.Set(name, expander.expand(value, nil, true))
+		}
+		request.Body = form.Encode()
+		if !hasHeader(request.Headers, "Content-Type") {
+			request.Headers["Content-Type"] = formContentType
+		}
+	case isFormContentType(request.Headers):
+		request.Body = expander.expand(step.Body, url.QueryEscape, true)
+	default:
+		request.Body = expander.expand(step.Body, nil, true)
+	}
+	return request, expander.err
+}
+
+// stepExpander replaces the references in a step's request, keeping the first reference that
+// could not be resolved, an undefined variable or a secret that could not be read, as its error.
+type stepExpander struct {
+	variables map[string]string
+	err       error
+}
+
+// expand replaces the references in value in a single pass, so that an extracted value that
+// looks like a reference is never expanded itself. escape, if any, is applied to each value
+// substituted. Secret references are left alone unless secrets is set.
+func (expander *stepExpander) expand(value string, escape func(string) string, secrets bool) string {
+	if escape == nil {
+		escape = func(value string) string { return value }
+	}
+	return referencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		match := referencePattern.FindStringSubmatch(reference)
+		if match[3] != "" {
+			value, ok := expander.variables[match[3]]
+			if !ok {
+				if expander.err == nil {
+					expander.err = fmt.Errorf("undefined variable %s, it is not extracted by an earlier step", reference)
+				}
+				return ""
+			}
+			return escape(value)
+		}
+		if !secrets {
+			return reference
+		}
+		secret := Secret{Env: match[2]}
+		if match[1] == "file" {
+			secret = Secret{File: match[2]}
+		}
+		value, err := secret.Value()
+		if err != nil {
+			if expander.err == nil {
+				expander.err = fmt.Errorf("secret %s: %w", secret, err)
+			}
+			return ""
+		}
+		return escape(value)
+	})
+}
+
+// VariableReferences returns the names of the extracted values value refers to as ${name}.
+func VariableReferences(value string) []string {
+	var names []string
+	for _, match := range referencePattern.FindAllStringSubmatch(value, -1) {
+		if match[3] != "" {
+			names = append(names, match[3])
+		}
+	}
+	return names
+}
+
+// hasHeader reports whether headers sets name, in any case.
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFormContentType reports whether headers declare a URL-encoded form body.
+func isFormContentType(headers map[string]string) bool {
+	for name, value := range headers {
+		if strings.EqualFold(name, "Content-Type") && strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), formContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusMismatch describes how the step's response differs from its expected status and location.
+func (step Step) statusMismatch(result StepResult) string {
+	expectedStatusCode := step.expectedStatusCode()
+	statusMatch := compareStatusCodes(result.StatusCode, expectedStatusCode)
+	switch {
+	case !statusMatch && step.ExpectedLocation != "" && !compareLocations(result.Location, step.ExpectedLocation):
+		return fmt.Sprintf("resolved with %d, expected %d, and redirect location %s did not match %s", result.StatusCode, expectedStatusCode, result.Location, step.ExpectedLocation)
+	case !statusMatch:
+		return fmt.Sprintf("resolved with %d, expected %d", result.StatusCode, expectedStatusCode)
+	default:
+		return fmt.Sprintf("resolved with %d, but redirect location %s did not match %s", result.StatusCode, result.Location, step.ExpectedLocation)
+	}
+}
+
+func (step Step) expectedStatusCode() int {
+	if step.ExpectedStatusCode == 0 {
+		return defaultStepStatusCode
+	}
+	return step.ExpectedStatusCode
+}
+
+// extract returns the value selected by the extraction from a response.
+func (extraction Extraction) extract(body string, header http.Header) (string, error) {
+	switch {
+	case extraction.Regex != "":
+		re, err := regexp.Compile(extraction.Regex)
+		if err != nil {
+			return "", fmt.Errorf("invalid regex: %w", err)
+		}
+		match := re.FindStringSubmatch(body)
+		if match == nil {
+			return "", fmt.Errorf("regex /%s/ did not match", extraction.Regex)
+		}
+		if len(match) > 1 {
+			return match[1], nil
+		}
+		return match[0], nil
+	case extraction.JSON != "":
+		path, err := compileJSONPath(extraction.JSON)
+		if err != nil {
+			return "", fmt.Errorf("invalid JSONPath: %w", err)
+		}
+		var document any
+		if err := json.Unmarshal([]byte(body), &document); err != nil {
+			return "", fmt.Errorf("response is not valid JSON: %w", err)
+		}
+		values := path.selectNodes(document)
+		if len(values) == 0 {
+			return "", fmt.Errorf("%s selected nothing", extraction.JSON)
+		}
+		return renderJSONValue(values[0]), nil
+	case extraction.Header != "":
+		value := header.Get(extraction.Header)
+		if value == "" {
+			return "", fmt.Errorf("no %s header", http.CanonicalHeaderKey(extraction.Header))
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("one of regex, json or header is required")
+	}
+}
+
+// stepsResultsStrings reports each step and its assertions, naming the step on every line.
+func stepsResultsStrings(results AppCheckStatus) []string {
+	var output []string
+	for _, step := range results.Steps {
+		label := fmt.Sprintf("Step %d", step.Number)
+		if step.Name != "" {
+			label += " (" + step.Name + ")"
+		}
+		if step.Skipped {
+			output = append(output, fmt.Sprintf("Skipped: %s after an earlier step failed", label))
+			continue
+		}
+
+		request := fmt.Sprintf("%s %s %s", label, step.Method, step.URL)
+		switch {
+		case step.StatusCode == 0:
+			output = append(output, fmt.Sprintf("Failure: %s failed: %s", request, step.Err))
+			continue
+		case step.StatusOk:
+			output = append(output, fmt.Sprintf("Success: %s resolved with %d", request, step.StatusCode))
+		default:
+			output = append(output, fmt.Sprintf("Failure: %s %s", request, results.Application.Steps[step.Number-1].statusMismatch(step)))
+		}
+
+		var lines []string
+		for _, result := range step.ContentResults {
+			lines = append(lines, contentResultString(AppCheckStatus{Application: results.Application}, result, false))
+		}
+		for _, result := range step.JSONResults {
+			lines = append(lines, jsonResultString(result))
+		}
+		for _, result := range step.HTMLResults {
+			lines = append(lines, htmlResultString(result))
+		}
+		for _, result := range step.XMLResults {
+			lines = append(lines, xmlResultString(result))
+		}
+		for _, result := range step.HeaderResults {
+			lines = append(lines, headerResultString(result))
+		}
+		if step.Err != "" {
+			lines = append(lines, "Failure: "+step.Err)
+		}
+		for _, line := range lines {
+			// "Failure: Header ..." becomes "Failure: Step 2 (login) Header ..."
+			outcome, detail, _ := strings.Cut(line, ": ")
+			output = append(output, fmt.Sprintf("%s: %s %s", outcome, label, detail))
+		}
+	}
+	return output
+}
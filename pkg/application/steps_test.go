@@ -0,0 +1,288 @@
+package application
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// newILLiadServer serves a login form with a per-session token, a login handler that
+// sets a session cookie and redirects to the landing page, and a landing page that
+// requires the cookie.
+func newILLiadServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /illiad/logon.html", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "form", Value: "f1", Path: "/"})
+		fmt.Fprint(w, `<html><form method="post"><input type="hidden" name="token" value="abc123"></form></html>`)
+	})
+	mux.HandleFunc("POST /illiad/logon.html", func(w http.ResponseWriter, r *http.Request) {
+		form, err := r.Cookie("form")
+		if err != nil || form.Value != "f1" || r.FormValue("token") != "abc123" || r.FormValue("username") != "aswa" {
+			http.Error(w, "invalid login", http.StatusForbidden)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+		http.Redirect(w, r, "/illiad/main.html", http.StatusFound)
+	})
+	mux.HandleFunc("GET /illiad/main.html", func(w http.ResponseWriter, r *http.Request) {
+		if session, err := r.Cookie("session"); err != nil || session.Value != "s1" {
+			http.Redirect(w, r, "/illiad/logon.html", http.StatusFound)
+			return
+		}
+		w.Header().Set("X-Request-Id", "req-42")
+		fmt.Fprint(w, `<html><h1>Welcome to ILLiad</h1></html>`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestStepsUnmarshalYAML(t *testing.T) {
+	const config = `
+name: illiad-login
+steps:
+  - name: login-page
+    url: 'https://illiad.library.nyu.edu/illiad/logon.html'
+    extract:
+      token:
+        regex: 'name="token" value="([^"]+)"'
+  - name: login
+    url: 'https://illiad.library.nyu.edu/illiad/logon.html'
+    method: POST
+    headers:
+      Content-Type: application/x-www-form-urlencoded
+    body: 'username=aswa&token=${token}'
+    expected_html:
+      - css: 'h1'
+        text_contains: 'Welcome'
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+
+	require.Len(t, app.Steps, 2)
+	assert.True(t, app.hasSteps())
+	assert.Equal(t, "https://illiad.library.nyu.edu/illiad/logon.html", app.Target())
+	assert.Equal(t, `name="token" value="([^"]+)"`, app.Steps[0].Extract["token"].Regex)
+	assert.Equal(t, "username=aswa&token=${token}", app.Steps[1].Body)
+	require.Len(t, app.Steps[1].ExpectedHTML, 1)
+}
+
+func TestExtraction(t *testing.T) {
+	header := http.Header{"X-Request-Id": {"req-42"}}
+
+	var tests = []struct {
+		description string
+		extraction  Extraction
+		body        string
+		want        string
+		wantErr     string
+	}{
+		{"Regex group", Extraction{Regex: `value="([^"]+)"`}, `<input value="abc123">`, "abc123", ""},
+		{"Regex without group", Extraction{Regex: `alma\d+`}, `docid=alma990097005700107871`, "alma990097005700107871", ""},
+		{"Regex without match", Extraction{Regex: `token=(\w+)`}, `<html></html>`, "", `regex /token=(\w+)/ did not match`},
+		{"Invalid regex", Extraction{Regex: `(`}, ``, "", "invalid regex"},
+		{"JSONPath", Extraction{JSON: `$.docs[0].pnx.control.recordid[0]`}, `{"docs":[{"pnx":{"control":{"recordid":["alma1"]}}},{"pnx":{"control":{"recordid":["alma2"]}}}]}`, "alma1", ""},
+		{"JSONPath number", Extraction{JSON: `$.info.total`}, `{"info":{"total":42}}`, "42", ""},
+		{"JSONPath selects nothing", Extraction{JSON: `$.docs[0]`}, `{"docs":[]}`, "", "$.docs[0] selected nothing"},
+		{"Not JSON", Extraction{JSON: `$.docs`}, `<html>`, "", "response is not valid JSON"},
+		{"Header", Extraction{Header: "x-request-id"}, ``, "req-42", ""},
+		{"Missing header", Extraction{Header: "x-session"}, ``, "", "no X-Session header"},
+		{"Nothing to extract", Extraction{}, ``, "", "one of regex, json or header is required"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			value, err := test.extraction.extract(test.body, header)
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, value)
+		})
+	}
+}
+
+func TestStepRequest(t *testing.T) {
+	t.Setenv(testSecretEnv, "p@ss&word")
+	variables := map[string]string{"id": "a b/c", "token": "x+y=", "injected": "${secret:env:" + testSecretEnv + "}"}
+	form := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+
+	var tests = []struct {
+		description string
+		step        Step
+		wantURL     string
+		wantHeaders map[string]string
+		wantBody    string
+		wantErr     string
+	}{
+		{
+			description: "Values are escaped in the path and the query",
+			step:        Step{URL: "https://ill.library.nyu.edu/items/${id}?token=${token}"},
+			wantURL:     "https://ill.library.nyu.edu/items/a%20b%2Fc?token=x%2By%3D",
+			wantHeaders: map[string]string{},
+		},
+		{
+			description: "Values are escaped in a form body",
+			step:        Step{URL: "https://ill.library.nyu.edu/", Headers: form, Body: "token=${token}"},
+			wantURL:     "https://ill.library.nyu.edu/",
+			wantHeaders: form,
+			wantBody:    "token=x%2By%3D",
+		},
+		{
+			description: "Values are not escaped in other bodies",
+			step:        Step{URL: "https://ill.library.nyu.edu/", Body: `{"token":"${token}"}`},
+			wantURL:     "https://ill.library.nyu.edu/",
+			wantHeaders: map[string]string{},
+			wantBody:    `{"token":"x+y="}`,
+		},
+		{
+			description: "Form fields with a secret",
+			step:        Step{URL: "https://ill.library.nyu.edu/", BodyForm: map[string]string{"username": "aswa", "password": "${secret:env:" + testSecretEnv + "}", "token": "${token}"}},
+			wantURL:     "https://ill.library.nyu.edu/",
+			wantHeaders: form,
+			wantBody:    "password=p%40ss%26word&token=x%2By%3D&username=aswa",
+		},
+		{
+			description: "Secret in a header",
+			step:        Step{URL: "https://ill.library.nyu.edu/", Headers: map[string]string{"X-Password": "${secret:env:" + testSecretEnv + "}"}},
+			wantURL:     "https://ill.library.nyu.edu/",
+			wantHeaders: map[string]string{"X-Password": "p@ss&word"},
+		},
+		{
+			description: "An extracted value is never expanded itself",
+			step:        Step{URL: "https://ill.library.nyu.edu/", Headers: map[string]string{"X-Token": "${injected}"}},
+			wantURL:     "https://ill.library.nyu.edu/",
+			wantHeaders: map[string]string{"X-Token": "${secret:env:" + testSecretEnv + "}"},
+		},
+		{
+			description: "Undefined variable",
+			step:        Step{URL: "https://ill.library.nyu.edu/", Headers: map[string]string{"X-Token": "${tokne}"}},
+			wantErr:     "undefined variable ${tokne}, it is not extracted by an earlier step",
+		},
+		{
+			description: "Missing secret",
+			step:        Step{URL: "https://ill.library.nyu.edu/", Body: "${secret:env:ASWA_TEST_MISSING_SECRET}"},
+			wantErr:     "secret env:ASWA_TEST_MISSING_SECRET: environment variable ASWA_TEST_MISSING_SECRET is not set",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			request, err := Application{Name: "illiad"}.stepRequest(test.step, variables)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantURL, request.URL)
+			assert.Equal(t, test.wantHeaders, request.Headers)
+			assert.Equal(t, test.wantBody, request.Body)
+		})
+	}
+}
+
+func TestGetStatus_Steps(t *testing.T) {
+	srv := newILLiadServer(t)
+	text := func(s string) *string { return &s }
+
+	app := &Application{
+		Name:    "illiad-login",
+		Timeout: 2 * time.Second,
+		Steps: []Step{
+			{
+				Name:    "login-page",
+				URL:     srv.URL + "/illiad/logon.html",
+				Extract: map[string]Extraction{"token": {Regex: `name="token" value="([^"]+)"`}},
+			},
+			{
+				Name:         "login",
+				URL:          srv.URL + "/illiad/logon.html",
+				Method:       http.MethodPost,
+				Headers:      map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				Body:         "username=aswa&token=${token}",
+				ExpectedHTML: []HTMLAssertion{{CSS: "h1", TextContains: text("Welcome")}},
+				Extract:      map[string]Extraction{"request_id": {Header: "X-Request-Id"}},
+			},
+			{
+				Name:               "landing-page",
+				URL:                srv.URL + "/illiad/main.html?request=${request_id}",
+				FollowRedirects:    new(bool),
+				ExpectedStatusCode: http.StatusOK,
+			},
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.IsOk(), status.String())
+	assert.Equal(t, "Success: Step 1 (login-page) GET "+srv.URL+"/illiad/logon.html resolved with 200\n"+
+		"Success: Step 2 (login) POST "+srv.URL+"/illiad/logon.html resolved with 200\n"+
+		"Success: Step 2 (login) HTML css h1 text contains \"Welcome\"\n"+
+		"Success: Step 3 (landing-page) GET "+srv.URL+"/illiad/main.html?request=req-42 resolved with 200",
+		outputWithoutTimings(status))
+}
+
+func TestGetStatus_StepFails(t *testing.T) {
+	srv := newILLiadServer(t)
+
+	app := &Application{
+		Name:    "illiad-login",
+		Timeout: 2 * time.Second,
+		Steps: []Step{
+			{Name: "login-page", URL: srv.URL + "/illiad/logon.html"},
+			{
+				Name:    "login",
+				URL:     srv.URL + "/illiad/logon.html",
+				Method:  http.MethodPost,
+				Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				Body:    "username=aswa&token=stale",
+			},
+			{Name: "landing-page", URL: srv.URL + "/illiad/main.html"},
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.False(t, status.IsOk())
+	require.Len(t, status.Steps, 3)
+	assert.True(t, status.Steps[0].Ok())
+	assert.False(t, status.Steps[1].Ok(), "a stale token is rejected")
+	assert.True(t, status.Steps[2].Skipped)
+	assert.Equal(t, "Success: Step 1 (login-page) GET "+srv.URL+"/illiad/logon.html resolved with 200\n"+
+		"Failure: Step 2 (login) POST "+srv.URL+"/illiad/logon.html resolved with 403, expected 200\n"+
+		"Skipped: Step 3 (landing-page) after an earlier step failed",
+		outputWithoutTimings(status))
+}
+
+func TestGetStatus_StepExtractionFails(t *testing.T) {
+	srv := newILLiadServer(t)
+
+	app := &Application{
+		Name:    "illiad-login",
+		Timeout: 2 * time.Second,
+		Steps: []Step{
+			{URL: srv.URL + "/illiad/logon.html", Extract: map[string]Extraction{"token": {Regex: `name="csrf" value="([^"]+)"`}}},
+			{URL: srv.URL + "/illiad/main.html"},
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.False(t, status.IsOk())
+	assert.Equal(t, `step 1: could not extract token: regex /name="csrf" value="([^"]+)"/ did not match`, status.Error)
+	assert.Equal(t, "Success: Step 1 GET "+srv.URL+"/illiad/logon.html resolved with 200\n"+
+		`Failure: Step 1 could not extract token: regex /name="csrf" value="([^"]+)"/ did not match`+"\n"+
+		"Skipped: Step 2 after an earlier step failed",
+		outputWithoutTimings(status))
+}
@@ -0,0 +1,184 @@
+package application
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"regexp"
+	"time"
+)
+
+const (
+	// defaultTCPTimeout bounds a TCP check when neither timeout nor connect_timeout is set.
+	defaultTCPTimeout = 10 * time.Second
+	// maxTCPResponseBytes caps how much of a TCP response is read while looking for expect.
+	maxTCPResponseBytes = 4096
+	// maxTCPActualLength caps how much of the response is shown when expect does not match.
+	maxTCPActualLength = 200
+)
+
+// TCPCheck configures a type: tcp check of a host:port. With tls the connection is upgraded
+// with a TLS handshake, using the check's tls settings. send is written once connected, and
+// expect is a Go regular expression the data received (e.g. a banner) must match.
+type TCPCheck struct {
+	Address        string        `yaml:"address"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	TLS            bool          `yaml:"tls"`
+	Send           string        `yaml:"send"`
+	Expect         string        `yaml:"expect"`
+}
+
+// getTCPStatus connects to the configured address and runs the optional handshake, send and expect.
+// Connection and handshake failures fail the status; a response that does not match expect fails
+// the content, like expected_content for HTTP checks.
+func (test Application) getTCPStatus() *AppCheckStatus {
+	status := &AppCheckStatus{Application: &test, StatusContentOk: true, StatusCSPOk: true}
+	if test.TCP == nil || test.TCP.Address == "" {
+		status.Error = "has no tcp.address"
+		return status
+	}
+	check := test.TCP
+
+	timeout := test.Timeout
+	if timeout <= 0 {
+		timeout = max(check.ConnectTimeout, defaultTCPTimeout)
+	}
+	connectTimeout := check.ConnectTimeout
+	if connectTimeout <= 0 || connectTimeout > timeout {
+		connectTimeout = timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	recordError := func(err error) {
+		log.Printf("[%s] TCP error: %v", test.Name, err)
+		status.Error = err.Error()
+	}
+	fail := func(err error) *AppCheckStatus {
+		recordError(err)
+		status.Timings.Total = time.Since(start)
+		return status
+	}
+
+	dialer := net.Dialer{Timeout: connectTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", check.Address)
+	if err != nil {
+		return fail(fmt.Errorf("could not connect: %w", err))
+	}
+	defer conn.Close()
+	status.Timings.Connect = time.Since(start)
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var state *tls.ConnectionState
+	if check.TLS {
+		config, err := test.TLS.clientConfig()
+		if err != nil {
+			return fail(err)
+		}
+		if config == nil {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config.ServerName = test.hostname()
+		}
+		handshakeStart := time.Now()
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			status.CertResults = certErrorResults(test, err)
+			return fail(fmt.Errorf("TLS handshake failed: %w", err))
+		}
+		status.Timings.TLSHandshake = time.Since(handshakeStart)
+		connState := tlsConn.ConnectionState()
+		state = &connState
+		conn = tlsConn
+	}
+	status.StatusOk = true
+
+	if check.Send != "" {
+		if _, err := io.WriteString(conn, check.Send); err != nil {
+			status.StatusContentOk = false
+			recordError(fmt.Errorf("sending: %w", err))
+		}
+	}
+	if check.Expect != "" && status.Error == "" {
+		status.StatusContentOk, status.ActualContent, err = readExpected(conn, check.Expect, &status.Timings, start)
+		if err != nil {
+			recordError(err)
+		}
+	}
+
+	status.Timings.Total = time.Since(start)
+	status.ResponseTimeExceeded, status.ResponseTimeWarning = test.evaluateResponseTime(status.Timings.Total)
+	status.Certificates = certificateChain(state)
+	status.CertResults = test.evaluateCertificates(state, time.Now())
+	status.TLSResults = test.evaluateTLS(state)
+	return status
+}
+
+// readExpected reads from conn until the data received matches expect, the peer closes the
+// connection, the deadline passes or maxTCPResponseBytes have been read.
+func readExpected(conn net.Conn, expect string, timings *Timings, start time.Time) (bool, string, error) {
+	re, err := regexp.Compile(expect)
+	if err != nil {
+		return false, "", fmt.Errorf("invalid expect: %w", err)
+	}
+
+	var received []byte
+	chunk := make([]byte, 1024)
+	for len(received) < maxTCPResponseBytes {
+		n, err := conn.Read(chunk)
+		if n > 0 && timings.TTFB == 0 {
+			timings.TTFB = time.Since(start)
+		}
+		received = append(received, chunk[:n]...)
+		if re.Match(received) {
+			return true, string(received), nil
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return false, string(received), fmt.Errorf("reading response: %w", err)
+		}
+	}
+	return false, string(received), nil
+}
+
+// tcpResultsStrings reports the connection and expect results of a TCP check.
+func tcpResultsStrings(results AppCheckStatus) []string {
+	app := results.Application
+	if !results.StatusOk {
+		return []string{fmt.Sprintf("Failure: %s %s", app.label(), results.Error)}
+	}
+
+	connected := fmt.Sprintf("Success: %s connected", app.label())
+	if app.TCP.TLS {
+		connected += " with TLS"
+	}
+	output := []string{connected}
+
+	switch {
+	case app.TCP.Expect != "" && results.StatusContentOk:
+		output = append(output, fmt.Sprintf("Success: %s response matched /%s/", app.label(), app.TCP.Expect))
+	case app.TCP.Expect != "":
+		actual := results.ActualContent
+		if len(actual) > maxTCPActualLength {
+			actual = actual[:maxTCPActualLength] + "..."
+		}
+		failure := fmt.Sprintf("Failure: %s response did not match /%s/, actual: %q", app.label(), app.TCP.Expect, actual)
+		if results.Error != "" {
+			failure += " (" + results.Error + ")"
+		}
+		output = append(output, failure)
+	case results.Error != "":
+		output = append(output, fmt.Sprintf("Failure: %s %s", app.label(), results.Error))
+	}
+	return output
+}
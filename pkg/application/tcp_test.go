@@ -0,0 +1,188 @@
+package application
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// startSMTPServer runs a stand-in SMTP server that sends a banner and answers EHLO,
+// then closes the connection.
+func startSMTPServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+				_, _ = conn.Write([]byte("220 smtp-relay.library.nyu.edu ESMTP Postfix\r\n"))
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.HasPrefix(line, "EHLO ") {
+					_, _ = conn.Write([]byte("250-smtp-relay.library.nyu.edu\r\n250 STARTTLS\r\n"))
+				} else {
+					_, _ = conn.Write([]byte("502 5.5.2 Error: command not recognized\r\n"))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestTCPCheckUnmarshalYAML(t *testing.T) {
+	const config = `
+name: smtp-relay
+type: tcp
+timeout: 5s
+tcp:
+  address: smtp-relay.library.nyu.edu:25
+  connect_timeout: 2s
+  send: "EHLO aswa\r\n"
+  expect: '^220 .*\r\n250 '
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+
+	assert.True(t, app.isTCP())
+	require.NotNil(t, app.TCP)
+	assert.Equal(t, "smtp-relay.library.nyu.edu:25", app.Target())
+	assert.Equal(t, "smtp-relay.library.nyu.edu", app.hostname())
+	assert.Equal(t, 2*time.Second, app.TCP.ConnectTimeout)
+	assert.Equal(t, "EHLO aswa\r\n", app.TCP.Send)
+}
+
+func TestGetStatus_TCP(t *testing.T) {
+	address := startSMTPServer(t)
+
+	var tests = []struct {
+		description string
+		check       TCPCheck
+		wantOk      bool
+		want        string
+	}{
+		{
+			"Connect only",
+			TCPCheck{Address: address},
+			true,
+			"Success: TCP " + address + " connected",
+		},
+		{
+			"Banner",
+			TCPCheck{Address: address, Expect: `^220 \S+ ESMTP`},
+			true,
+			"Success: TCP " + address + " connected\n" +
+				"Success: TCP " + address + ` response matched /^220 \S+ ESMTP/`,
+		},
+		{
+			"Send and expect",
+			TCPCheck{Address: address, Send: "EHLO aswa\r\n", Expect: `(?m)^250 STARTTLS`},
+			true,
+			"Success: TCP " + address + " connected\n" +
+				"Success: TCP " + address + " response matched /(?m)^250 STARTTLS/",
+		},
+		{
+			"Unexpected response",
+			TCPCheck{Address: address, Send: "HELO aswa\r\n", Expect: `(?m)^250 `},
+			false,
+			"Success: TCP " + address + " connected\n" +
+				"Failure: TCP " + address + ` response did not match /(?m)^250 /, actual: "220 smtp-relay.library.nyu.edu ESMTP Postfix\r\n502 5.5.2 Error: command not recognized\r\n"`,
+		},
+		{
+			"Invalid expect",
+			TCPCheck{Address: address, Expect: "("},
+			false,
+			"Success: TCP " + address + " connected\n" +
+				"Failure: TCP " + address + " response did not match /(/, actual: \"\" (invalid expect: error parsing regexp: missing closing ): `(`)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			app := &Application{Name: "smtp-relay", Type: CheckTCP, Timeout: 2 * time.Second, TCP: &test.check}
+
+			status := app.GetStatus()
+
+			require.NotNil(t, status)
+			assert.True(t, status.StatusOk)
+			assert.Equal(t, test.wantOk, status.IsOk())
+			assert.Equal(t, test.want, outputWithoutTimings(status))
+		})
+	}
+}
+
+func TestGetStatus_TCPConnectionRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := ln.Addr().String()
+	ln.Close()
+
+	app := &Application{Name: "aleph-z3950", Type: CheckTCP, TCP: &TCPCheck{Address: address, ConnectTimeout: time.Second}}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.False(t, status.StatusOk)
+	assert.False(t, status.IsOk())
+	assert.Contains(t, status.String(), "Failure: TCP "+address+" could not connect: dial tcp "+address)
+}
+
+func TestGetStatus_TCPWithTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	address := srv.Listener.Addr().String()
+	caBundle := writeServerCA(t, srv)
+
+	app := &Application{
+		Name:                  "ldaps",
+		Type:                  CheckTCP,
+		Timeout:               2 * time.Second,
+		TLS:                   &TLSSettings{CABundle: caBundle, ServerName: "example.com"},
+		ExpectedCertHostnames: []string{"example.com"},
+		TCP: &TCPCheck{
+			Address: address,
+			TLS:     true,
+			Send:    "HEAD / HTTP/1.0\r\n\r\n",
+			Expect:  `^HTTP/1\.[01] 200`,
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.IsOk(), status.String())
+	assert.Positive(t, status.Timings.TLSHandshake)
+	assert.NotEmpty(t, status.Certificates)
+	assert.Equal(t, "Success: TCP "+address+" connected with TLS\n"+
+		"Success: TCP "+address+` response matched /^HTTP/1\.[01] 200/`+"\n"+
+		"Success: Certificate for 127.0.0.1 covers example.com",
+		outputWithoutTimings(status))
+
+	app.TLS = nil
+	status = app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.False(t, status.IsOk())
+	assert.Contains(t, status.Error, "TLS handshake failed")
+	require.Len(t, status.CertResults, 1)
+	assert.Equal(t, certInvalid, status.CertResults[0].Kind)
+}
@@ -0,0 +1,135 @@
+package application
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"time"
+)
+
+// Timings breaks down how long the probe of the original URL took, and how long fetching the
+// final page took when the check needs it. Phases that did not happen (e.g. DNS and connect on
+// a reused connection, TLS for plain HTTP) are zero, as are the phases a failed request did not
+// reach.
+type Timings struct {
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	TTFB         time.Duration // from sending the request to the first response byte
+	Total        time.Duration // from sending the request to receiving the response headers
+	Page         time.Duration // fetching the final page, following redirects and reading the body
+}
+
+// String renders the non-zero phases, e.g. "dns=3ms connect=12ms tls=40ms ttfb=95ms total=96ms page=180ms".
+func (timings Timings) String() string {
+	var phases []string
+	for _, phase := range []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"dns", timings.DNS},
+		{"connect", timings.Connect},
+		{"tls", timings.TLSHandshake},
+		{"ttfb", timings.TTFB},
+		{"total", timings.Total},
+		{"page", timings.Page},
+	} {
+		if phase.duration > 0 {
+			phases = append(phases, phase.name+"="+roundDuration(phase.duration).String())
+		}
+	}
+	return strings.Join(phases, " ")
+}
+
+// roundDuration keeps timings readable in output without hiding sub-millisecond phases.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// timingTransport records per-phase timings of each round trip via httptrace.
+// A later round trip (e.g. the GET fallback after an unsupported HEAD) replaces
+// the timings of an earlier one.
+type timingTransport struct {
+	base    http.RoundTripper
+	timings *Timings
+}
+
+func newTimingTransport(base http.RoundTripper, timings *Timings) *timingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &timingTransport{base: base, timings: timings}
+}
+
+func (t *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var timings Timings
+	var dnsStart, connectStart, tlsStart time.Time
+	start := time.Now()
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			if !dnsStart.IsZero() {
+				timings.DNS = time.Since(dnsStart)
+			}
+		},
+		ConnectStart: func(string, string) { connectStart = time.Now() },
+		ConnectDone: func(string, string, error) {
+			if !connectStart.IsZero() {
+				timings.Connect = time.Since(connectStart)
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			if !tlsStart.IsZero() {
+				timings.TLSHandshake = time.Since(tlsStart)
+			}
+		},
+		GotFirstResponseByte: func() { timings.TTFB = time.Since(start) },
+	}
+
+	resp, err := t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	timings.Total = time.Since(start)
+	*t.timings = timings
+	return resp, err
+}
+
+// responseTime returns the time max_response_time and warn_response_time apply to: the probe,
+// plus fetching the final page when the check fetched it, since its assertions apply to that page.
+func (timings Timings) responseTime() time.Duration {
+	return timings.Total + timings.Page
+}
+
+// evaluateResponseTime compares a response time with max_response_time and warn_response_time.
+func (test Application) evaluateResponseTime(total time.Duration) (exceeded, warning bool) {
+	if test.MaxResponseTime > 0 && total > test.MaxResponseTime {
+		return true, false
+	}
+	return false, test.WarnResponseTime > 0 && total > test.WarnResponseTime
+}
+
+// responseTimeString reports the response time, against the configured thresholds if any, or
+// how long a failed request took. It says when the time includes fetching the final page. A
+// warning is labelled "Slow" rather than "Failure" so that it does not trigger failure notifications.
+func responseTimeString(results AppCheckStatus) string {
+	app := results.Application
+	responded := "responded in " + roundDuration(results.Timings.responseTime()).String()
+	if results.Timings.Page > 0 {
+		responded += " including the final page"
+	}
+	switch {
+	case results.Error != "":
+		return fmt.Sprintf("Failure: %s request failed (%s)", app.label(), results.Timings)
+	case results.ResponseTimeExceeded:
+		return fmt.Sprintf("Failure: %s %s, over max_response_time %s (%s)", app.label(), responded, app.MaxResponseTime, results.Timings)
+	case results.ResponseTimeWarning:
+		return fmt.Sprintf("Slow: %s %s, over warn_response_time %s (%s)", app.label(), responded, app.WarnResponseTime, results.Timings)
+	default:
+		return fmt.Sprintf("Success: %s %s (%s)", app.label(), responded, results.Timings)
+	}
+}
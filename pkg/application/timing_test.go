@@ -0,0 +1,218 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimingsString(t *testing.T) {
+	var tests = []struct {
+		description string
+		timings     Timings
+		want        string
+	}{
+		{"All phases", Timings{DNS: 3 * time.Millisecond, Connect: 12 * time.Millisecond, TLSHandshake: 40 * time.Millisecond, TTFB: 95 * time.Millisecond, Total: 96 * time.Millisecond}, "dns=3ms connect=12ms tls=40ms ttfb=95ms total=96ms"},
+		{"Reused connection skips dns and connect", Timings{TTFB: 20400 * time.Microsecond, Total: 20600 * time.Microsecond}, "ttfb=20ms total=21ms"},
+		{"Sub-millisecond phases keep microseconds", Timings{Connect: 180400 * time.Nanosecond, Total: 2 * time.Millisecond}, "connect=180µs total=2ms"},
+		{"Final page fetched", Timings{TTFB: 20 * time.Millisecond, Total: 21 * time.Millisecond, Page: 180 * time.Millisecond}, "ttfb=20ms total=21ms page=180ms"},
+		{"Nothing recorded", Timings{}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.want, test.timings.String())
+		})
+	}
+}
+
+func TestEvaluateResponseTime(t *testing.T) {
+	var tests = []struct {
+		description  string
+		max, warn    time.Duration
+		total        time.Duration
+		wantExceeded bool
+		wantWarning  bool
+	}{
+		{"No limits", 0, 0, 10 * time.Second, false, false},
+		{"Under max", 2 * time.Second, 0, time.Second, false, false},
+		{"Over max", 2 * time.Second, 0, 3 * time.Second, true, false},
+		{"Over warn only", 2 * time.Second, time.Second, 1500 * time.Millisecond, false, true},
+		{"Over max and warn reports the failure", 2 * time.Second, time.Second, 3 * time.Second, true, false},
+		{"Warn without max", 0, time.Second, 1500 * time.Millisecond, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			app := Application{MaxResponseTime: test.max, WarnResponseTime: test.warn}
+			exceeded, warning := app.evaluateResponseTime(test.total)
+			assert.Equal(t, test.wantExceeded, exceeded)
+			assert.Equal(t, test.wantWarning, warning)
+		})
+	}
+}
+
+func TestResponseTimeString(t *testing.T) {
+	app := &Application{URL: "https://library.nyu.edu/", MaxResponseTime: 2 * time.Second, WarnResponseTime: time.Second}
+	timings := Timings{Connect: 12 * time.Millisecond, TTFB: 1490 * time.Millisecond, Total: 1500 * time.Millisecond}
+
+	assert.Equal(t, "Slow: URL https://library.nyu.edu/ responded in 1.5s, over warn_response_time 1s (connect=12ms ttfb=1.49s total=1.5s)",
+		responseTimeString(AppCheckStatus{Application: app, Timings: timings, ResponseTimeWarning: true}))
+	assert.Equal(t, "Failure: URL https://library.nyu.edu/ responded in 1.5s, over max_response_time 2s (connect=12ms ttfb=1.49s total=1.5s)",
+		responseTimeString(AppCheckStatus{Application: app, Timings: timings, ResponseTimeExceeded: true}))
+	assert.Equal(t, "Success: URL https://library.nyu.edu/ responded in 1.5s (connect=12ms ttfb=1.49s total=1.5s)",
+		responseTimeString(AppCheckStatus{Application: app, Timings: timings}))
+	assert.Equal(t, "Failure: URL https://library.nyu.edu/ request failed (connect=12ms ttfb=1.49s total=1.5s)",
+		responseTimeString(AppCheckStatus{Application: app, Timings: timings, Error: "unexpected EOF"}))
+
+	timings.Page = 800 * time.Millisecond
+	assert.Equal(t, "Failure: URL https://library.nyu.edu/ responded in 2.3s including the final page, over max_response_time 2s (connect=12ms ttfb=1.49s total=1.5s page=800ms)",
+		responseTimeString(AppCheckStatus{Application: app, Timings: timings, ResponseTimeExceeded: true}))
+}
+
+func TestTimingTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	var timings Timings
+	client := &http.Client{Transport: newTimingTransport(nil, &timings)}
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	closeResponseBody(resp.Body)
+
+	assert.Zero(t, timings.DNS, "an IP address needs no lookup")
+	assert.Positive(t, timings.Connect)
+	assert.Zero(t, timings.TLSHandshake, "plain HTTP has no handshake")
+	assert.GreaterOrEqual(t, timings.TTFB, 20*time.Millisecond)
+	assert.GreaterOrEqual(t, timings.Total, timings.TTFB)
+}
+
+func TestGetStatus_MaxResponseTime(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	var tests = []struct {
+		description  string
+		max, warn    time.Duration
+		wantOk       bool
+		wantExceeded bool
+		wantWarning  bool
+	}{
+		{"Within limits", time.Second, 500 * time.Millisecond, true, false, false},
+		{"Slow but under max", time.Second, 10 * time.Millisecond, true, false, true},
+		{"Over max", 10 * time.Millisecond, 0, false, true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			app := &Application{
+				Name:               "slow",
+				URL:                srv.URL,
+				ExpectedStatusCode: http.StatusOK,
+				Timeout:            2 * time.Second,
+				MaxResponseTime:    test.max,
+				WarnResponseTime:   test.warn,
+			}
+
+			status := app.GetStatus()
+
+			require.NotNil(t, status)
+			assert.True(t, status.StatusOk, "the status code itself still matched")
+			assert.Equal(t, test.wantOk, status.IsOk())
+			assert.Equal(t, test.wantExceeded, status.ResponseTimeExceeded)
+			assert.Equal(t, test.wantWarning, status.ResponseTimeWarning)
+			assert.GreaterOrEqual(t, status.Timings.TTFB, 50*time.Millisecond)
+			assert.Contains(t, status.String(), "responded in")
+		})
+	}
+}
+
+func TestGetStatus_MaxResponseTimeIncludesFinalPage(t *testing.T) {
+	// The HEAD probe is answered at once, but the page body is slow to arrive.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+			_, _ = w.Write([]byte("NYU Libraries"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "library",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		MaxResponseTime:    50 * time.Millisecond,
+		ExpectedContent:    ContentAssertions{{Value: "NYU Libraries"}},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.Less(t, status.Timings.Total, 50*time.Millisecond, "the probe alone is fast")
+	assert.True(t, status.ResponseTimeExceeded, "the page the content assertions apply to is slow")
+	assert.False(t, status.IsOk())
+	assert.Contains(t, status.String(), "including the final page, over max_response_time 50ms")
+}
+
+func TestGetStatus_TimingsWithoutThresholds(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("NYU Libraries"))
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "library",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		ExpectedContent:    ContentAssertions{{Value: "NYU Libraries"}},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.IsOk(), status.String())
+	assert.Positive(t, status.Timings.Total)
+	assert.Positive(t, status.Timings.Page, "fetching the final page is timed too")
+	assert.Contains(t, status.String(), "Success: URL "+srv.URL+" responded in")
+}
+
+func TestGetStatus_TimingsOfFailedRequest(t *testing.T) {
+	// The server accepts the connection and closes it without answering.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{Name: "library", URL: srv.URL, ExpectedStatusCode: http.StatusOK, Timeout: 2 * time.Second}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.NotEmpty(t, status.Error)
+	assert.Positive(t, status.Timings.Connect, "the phases reached before the failure are kept")
+	assert.Positive(t, status.Timings.Total)
+	assert.Contains(t, status.String(), "Failure: URL "+srv.URL+" request failed (connect=")
+}
+
+// outputWithoutTimings renders a status without its response time line, which varies from run to run.
+func outputWithoutTimings(status *AppCheckStatus) string {
+	stable := *status
+	stable.Timings = Timings{}
+	return stable.String()
+}
@@ -0,0 +1,229 @@
+package application
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kinds of TLS connection assertion.
+const (
+	tlsVersion = "version"
+	tlsCipher  = "cipher"
+)
+
+// TLSSettings configures the TLS client of a check and what it expects the negotiated
+// connection to look like. Versions are written as "1.0" through "1.3"; cipher suites use
+// their standard names, e.g. TLS_AES_128_GCM_SHA256.
+type TLSSettings struct {
+	ClientCert           string   `yaml:"client_cert"`
+	ClientKey            string   `yaml:"client_key"`
+	CABundle             string   `yaml:"ca_bundle"`
+	ServerName           string   `yaml:"server_name"`
+	MinVersion           string   `yaml:"min_version"`
+	MaxVersion           string   `yaml:"max_version"`
+	ExpectedMinVersion   string   `yaml:"expected_min_version"`
+	ExpectedCipherSuites []string `yaml:"expected_cipher_suites"`
+}
+
+// TLSResult is the outcome of one assertion on the negotiated TLS connection.
+type TLSResult struct {
+	Kind     string
+	Host     string
+	Ok       bool
+	Expected string
+	Actual   string
+}
+
+// clientConfig builds the TLS client configuration for the check. It returns nil when
+// nothing about the client needs to change, so the default transport is used.
+func (settings *TLSSettings) clientConfig() (*tls.Config, error) {
+	if settings == nil {
+		return nil, nil
+	}
+	if settings.ClientCert == "" && settings.ClientKey == "" && settings.CABundle == "" &&
+		settings.ServerName == "" && settings.MinVersion == "" && settings.MaxVersion == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{ServerName: settings.ServerName}
+
+	if settings.ClientCert != "" || settings.ClientKey != "" {
+		if settings.ClientCert == "" || settings.ClientKey == "" {
+			return nil, fmt.Errorf("tls client_cert and client_key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(settings.ClientCert, settings.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading tls client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if settings.CABundle != "" {
+		pem, err := os.ReadFile(settings.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("reading tls ca_bundle: %w", err)
+		}
+		// Extend rather than replace the system roots so that redirects to public hosts still verify.
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls ca_bundle %s contains no PEM certificates", settings.CABundle)
+		}
+		config.RootCAs = pool
+	}
+
+	var err error
+	if config.MinVersion, err = parseTLSVersion(settings.MinVersion); err != nil {
+		return nil, fmt.Errorf("tls min_version: %w", err)
+	}
+	if config.MaxVersion, err = parseTLSVersion(settings.MaxVersion); err != nil {
+		return nil, fmt.Errorf("tls max_version: %w", err)
+	}
+	return config, nil
+}
+
+// newTransport returns a transport using the check's TLS settings, or nil for the default transport.
+// server_name only applies to connections to the check's own host, not to hosts it is redirected
+// to or fetches assets from.
+func (test Application) newTransport() (http.RoundTripper, error) {
+	config, err := test.TLS.clientConfig()
+	if err != nil || config == nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	if config.ServerName == "" {
+		return transport, nil
+	}
+
+	serverName, host := config.ServerName, urlHostname(test.Target())
+	config.ServerName = ""
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		// Cloned at dial time, once the transport has added its ALPN protocols.
+		hostConfig := transport.TLSClientConfig.Clone()
+		dialHost, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		hostConfig.ServerName = dialHost
+		if strings.EqualFold(dialHost, host) {
+			hostConfig.ServerName = serverName
+		}
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		// The transport runs the handshake, so that it is traced like any other.
+		return tls.Client(conn, hostConfig), nil
+	}
+	return transport, nil
+}
+
+// tokenClient returns the client OAuth2 tokens are requested with: it uses the check's TLS
+// settings, so a token endpoint behind the same CA or mTLS can be reached, gives up after the
+// check's timeout, or defaultTokenTimeout when it has none, and does not follow redirects.
+func (test Application) tokenClient() (*http.Client, error) {
+	transport, err := test.newTransport()
+	if err != nil {
+		return nil, err
+	}
+	timeout := test.Timeout
+	if timeout <= 0 {
+		timeout = defaultTokenTimeout
+	}
+	return &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		Timeout:       timeout,
+		Transport:     transport,
+	}, nil
+}
+
+// closeIdleConnections releases the connections of a transport built for one attempt. The
+// default transport is shared by every check, so its connections are left alone.
+func closeIdleConnections(client *http.Client) {
+	if client.Transport != nil {
+		client.CloseIdleConnections()
+	}
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// parseTLSVersion accepts "1.2", "TLS1.2" or "TLS 1.2"; an empty string means the Go default.
+func parseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+	normalized := strings.TrimSpace(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(version)), "TLS"))
+	if parsed, ok := tlsVersions[normalized]; ok {
+		return parsed, nil
+	}
+	return 0, fmt.Errorf("unsupported TLS version %q, use one of 1.0, 1.1, 1.2 or 1.3", version)
+}
+
+// evaluateTLS runs the configured assertions against the negotiated connection of the probe.
+func (test Application) evaluateTLS(state *tls.ConnectionState) []TLSResult {
+	settings := test.TLS
+	if settings == nil || (settings.ExpectedMinVersion == "" && len(settings.ExpectedCipherSuites) == 0) {
+		return nil
+	}
+	host := test.hostname()
+
+	var results []TLSResult
+	if settings.ExpectedMinVersion != "" {
+		result := TLSResult{Kind: tlsVersion, Host: host, Expected: settings.ExpectedMinVersion, Actual: "no TLS"}
+		expected, err := parseTLSVersion(settings.ExpectedMinVersion)
+		if err != nil {
+			result.Actual = err.Error()
+		} else if state != nil {
+			result.Expected = tls.VersionName(expected)
+			result.Actual = tls.VersionName(state.Version)
+			result.Ok = state.Version >= expected
+		}
+		results = append(results, result)
+	}
+	if len(settings.ExpectedCipherSuites) > 0 {
+		result := TLSResult{Kind: tlsCipher, Host: host, Expected: strings.Join(settings.ExpectedCipherSuites, ", "), Actual: "no TLS"}
+		if state != nil {
+			result.Actual = tls.CipherSuiteName(state.CipherSuite)
+			result.Ok = slices.Contains(settings.ExpectedCipherSuites, result.Actual)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (result TLSResult) passed() bool {
+	return result.Ok
+}
+
+// tlsResultString formats one TLS connection assertion outcome.
+func tlsResultString(result TLSResult) string {
+	switch result.Kind {
+	case tlsVersion:
+		if result.Ok {
+			return fmt.Sprintf("Success: TLS for %s negotiated %s", result.Host, result.Actual)
+		}
+		return fmt.Sprintf("Failure: TLS for %s negotiated %s, expected at least %s", result.Host, result.Actual, result.Expected)
+	default:
+		if result.Ok {
+			return fmt.Sprintf("Success: TLS for %s negotiated cipher suite %s", result.Host, result.Actual)
+		}
+		return fmt.Sprintf("Failure: TLS for %s negotiated cipher suite %s, expected one of %s", result.Host, result.Actual, result.Expected)
+	}
+}
@@ -0,0 +1,393 @@
+package application
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// writeServerCA writes the test server's certificate as a PEM CA bundle.
+func writeServerCA(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// writeClientCert generates a self-signed client certificate and returns its PEM files and parsed form.
+func writeClientCert(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "aswa"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile, cert
+}
+
+func TestTLSSettingsUnmarshalYAML(t *testing.T) {
+	const config = `
+tls:
+  client_cert: /run/secrets/aswa.pem
+  client_key: /run/secrets/aswa-key.pem
+  ca_bundle: /etc/ssl/nyu-internal-ca.pem
+  server_name: internal.library.nyu.edu
+  min_version: '1.0'
+  expected_min_version: '1.2'
+  expected_cipher_suites:
+    - TLS_AES_128_GCM_SHA256
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+
+	assert.Equal(t, &TLSSettings{
+		ClientCert:           "/run/secrets/aswa.pem",
+		ClientKey:            "/run/secrets/aswa-key.pem",
+		CABundle:             "/etc/ssl/nyu-internal-ca.pem",
+		ServerName:           "internal.library.nyu.edu",
+		MinVersion:           "1.0",
+		ExpectedMinVersion:   "1.2",
+		ExpectedCipherSuites: []string{"TLS_AES_128_GCM_SHA256"},
+	}, app.TLS)
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	var tests = []struct {
+		version string
+		want    uint16
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1.0", tls.VersionTLS10, false},
+		{"1.2", tls.VersionTLS12, false},
+		{"TLS1.3", tls.VersionTLS13, false},
+		{"tls 1.1", tls.VersionTLS11, false},
+		{"SSL3", 0, true},
+		{"1.4", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			got, err := parseTLSVersion(test.version)
+			if test.wantErr {
+				assert.ErrorContains(t, err, "unsupported TLS version")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestTLSSettingsClientConfig(t *testing.T) {
+	certFile, keyFile, _ := writeClientCert(t)
+	notPEM := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	var tests = []struct {
+		description string
+		settings    *TLSSettings
+		wantNil     bool
+		wantErr     string
+	}{
+		{"No settings", nil, true, ""},
+		{"Assertions only keep the default transport", &TLSSettings{ExpectedMinVersion: "1.2"}, true, ""},
+		{"Client certificate", &TLSSettings{ClientCert: certFile, ClientKey: keyFile}, false, ""},
+		{"Client certificate without key", &TLSSettings{ClientCert: certFile}, false, "client_cert and client_key must be set together"},
+		{"Missing client certificate file", &TLSSettings{ClientCert: "missing.pem", ClientKey: keyFile}, false, "loading tls client certificate"},
+		{"Missing CA bundle", &TLSSettings{CABundle: "missing.pem"}, false, "reading tls ca_bundle"},
+		{"CA bundle without certificates", &TLSSettings{CABundle: notPEM}, false, "contains no PEM certificates"},
+		{"Invalid min version", &TLSSettings{MinVersion: "1.9"}, false, "tls min_version"},
+		{"Invalid max version", &TLSSettings{MaxVersion: "ssl"}, false, "tls max_version"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			config, err := test.settings.clientConfig()
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantNil, config == nil)
+		})
+	}
+}
+
+func TestEvaluateTLS(t *testing.T) {
+	state := &tls.ConnectionState{Version: tls.VersionTLS10, CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA}
+
+	var tests = []struct {
+		description string
+		settings    *TLSSettings
+		state       *tls.ConnectionState
+		want        []TLSResult
+	}{
+		{"No assertions", &TLSSettings{MinVersion: "1.0"}, state, nil},
+		{
+			"Fallback to TLS 1.0 is caught",
+			&TLSSettings{ExpectedMinVersion: "1.2"},
+			state,
+			[]TLSResult{{Kind: tlsVersion, Host: "library.nyu.edu", Expected: "TLS 1.2", Actual: "TLS 1.0"}},
+		},
+		{
+			"Version at or above the minimum",
+			&TLSSettings{ExpectedMinVersion: "1.0"},
+			state,
+			[]TLSResult{{Kind: tlsVersion, Host: "library.nyu.edu", Ok: true, Expected: "TLS 1.0", Actual: "TLS 1.0"}},
+		},
+		{
+			"Cipher suite in the list",
+			&TLSSettings{ExpectedCipherSuites: []string{"TLS_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"}},
+			state,
+			[]TLSResult{{Kind: tlsCipher, Host: "library.nyu.edu", Ok: true, Expected: "TLS_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", Actual: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"}},
+		},
+		{
+			"Cipher suite not in the list",
+			&TLSSettings{ExpectedCipherSuites: []string{"TLS_AES_128_GCM_SHA256"}},
+			state,
+			[]TLSResult{{Kind: tlsCipher, Host: "library.nyu.edu", Expected: "TLS_AES_128_GCM_SHA256", Actual: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"}},
+		},
+		{
+			"Plain HTTP",
+			&TLSSettings{ExpectedMinVersion: "1.2"},
+			nil,
+			[]TLSResult{{Kind: tlsVersion, Host: "library.nyu.edu", Expected: "1.2", Actual: "no TLS"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			app := Application{URL: "https://library.nyu.edu/", TLS: test.settings}
+			assert.Equal(t, test.want, app.evaluateTLS(test.state))
+		})
+	}
+}
+
+func TestTLSResultString(t *testing.T) {
+	assert.Equal(t, "Failure: TLS for library.nyu.edu negotiated TLS 1.0, expected at least TLS 1.2",
+		tlsResultString(TLSResult{Kind: tlsVersion, Host: "library.nyu.edu", Expected: "TLS 1.2", Actual: "TLS 1.0"}))
+	assert.Equal(t, "Success: TLS for library.nyu.edu negotiated TLS 1.3",
+		tlsResultString(TLSResult{Kind: tlsVersion, Host: "library.nyu.edu", Ok: true, Expected: "TLS 1.2", Actual: "TLS 1.3"}))
+	assert.Equal(t, "Failure: TLS for library.nyu.edu negotiated cipher suite TLS_RSA_WITH_RC4_128_SHA, expected one of TLS_AES_128_GCM_SHA256",
+		tlsResultString(TLSResult{Kind: tlsCipher, Host: "library.nyu.edu", Expected: "TLS_AES_128_GCM_SHA256", Actual: "TLS_RSA_WITH_RC4_128_SHA"}))
+	assert.Equal(t, "Success: TLS for library.nyu.edu negotiated cipher suite TLS_AES_128_GCM_SHA256",
+		tlsResultString(TLSResult{Kind: tlsCipher, Host: "library.nyu.edu", Ok: true, Actual: "TLS_AES_128_GCM_SHA256"}))
+}
+
+func TestGetStatus_CustomCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:                  "internal",
+		URL:                   srv.URL,
+		ExpectedStatusCode:    http.StatusOK,
+		Timeout:               2 * time.Second,
+		ExpectedCertHostnames: []string{"example.com"},
+		TLS: &TLSSettings{
+			CABundle:           writeServerCA(t, srv),
+			ExpectedMinVersion: "1.2",
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.IsOk(), status.String())
+	assert.NotEmpty(t, status.Certificates, "the chain is recorded once the CA is trusted")
+	require.Len(t, status.TLSResults, 1)
+	assert.Equal(t, "TLS 1.3", status.TLSResults[0].Actual)
+}
+
+func TestGetStatus_ServerNameOverride(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	caBundle := writeServerCA(t, srv)
+
+	var tests = []struct {
+		serverName string
+		wantOk     bool
+	}{
+		{"example.com", true},
+		{"library.nyu.edu", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.serverName, func(t *testing.T) {
+			app := &Application{
+				Name:               "internal",
+				URL:                srv.URL,
+				ExpectedStatusCode: http.StatusOK,
+				Timeout:            2 * time.Second,
+				TLS:                &TLSSettings{CABundle: caBundle, ServerName: test.serverName},
+			}
+
+			status := app.GetStatus()
+
+			assert.Equal(t, test.wantOk, status.IsOk(), status.String())
+		})
+	}
+}
+
+func TestGetStatus_ServerNameOnlyForConfiguredHost(t *testing.T) {
+	var mu sync.Mutex
+	var serverNames []string
+	recordServerName := &tls.Config{GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		mu.Lock()
+		serverNames = append(serverNames, hello.ServerName)
+		mu.Unlock()
+		return nil, nil
+	}}
+
+	other := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("landing page"))
+	}))
+	other.TLS = recordServerName
+	other.StartTLS()
+	t.Cleanup(other.Close)
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, otherURL, http.StatusFound)
+	}))
+	srv.TLS = recordServerName
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "internal",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusFound,
+		Timeout:            2 * time.Second,
+		ExpectedContent:    ContentAssertions{{Value: "landing page"}},
+		TLS:                &TLSSettings{CABundle: writeServerCA(t, srv), ServerName: "example.com"},
+	}
+
+	app.GetStatus()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, serverNames, "example.com", "the configured host is dialed with server_name")
+	assert.Contains(t, serverNames, "localhost", "the host redirected to is dialed with its own name")
+}
+
+func TestGetStatus_ClientCertificate(t *testing.T) {
+	certFile, keyFile, clientCert := writeClientCert(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	caBundle := writeServerCA(t, srv)
+
+	var tests = []struct {
+		description string
+		settings    *TLSSettings
+		wantOk      bool
+	}{
+		{"With client certificate", &TLSSettings{CABundle: caBundle, ClientCert: certFile, ClientKey: keyFile}, true},
+		{"Without client certificate", &TLSSettings{CABundle: caBundle}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			app := &Application{
+				Name:               "mtls",
+				URL:                srv.URL,
+				ExpectedStatusCode: http.StatusOK,
+				Timeout:            2 * time.Second,
+				TLS:                test.settings,
+			}
+
+			status := app.GetStatus()
+
+			assert.Equal(t, test.wantOk, status.IsOk(), status.String())
+		})
+	}
+}
+
+func TestGetStatus_TLSVersion(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	app := &Application{
+		Name:               "legacy",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		TLS:                &TLSSettings{CABundle: writeServerCA(t, srv), ExpectedMinVersion: "1.3"},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk)
+	assert.False(t, status.IsOk(), "a downgraded connection fails the check")
+	assert.Contains(t, status.String(), "negotiated TLS 1.2, expected at least TLS 1.3")
+
+	app.TLS.MinVersion = "1.3"
+	status = app.GetStatus()
+	assert.False(t, status.StatusOk, "a client refusing the downgrade cannot connect")
+	assert.NotEmpty(t, status.Error)
+}
+
+func TestGetStatus_InvalidTLSSettings(t *testing.T) {
+	app := &Application{
+		Name:               "misconfigured",
+		URL:                "https://library.nyu.edu/",
+		ExpectedStatusCode: http.StatusOK,
+		TLS:                &TLSSettings{CABundle: "missing.pem"},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.False(t, status.IsOk())
+	assert.Contains(t, status.Error, "reading tls ca_bundle")
+}
@@ -0,0 +1,150 @@
+package application
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// XMLAssertion is an expectation on the nodes selected from an XML response by an XPath
+// expression. Prefixed names in the expression resolve through the check's xml_namespaces
+// (or, if unbound, match the prefix used in the document); unprefixed names match by local
+// name in any namespace. With no comparison, at least one node must match. equals and regex
+// pass when any selected node satisfies them.
+type XMLAssertion struct {
+	XPath    string  `yaml:"xpath"`
+	Exists   *bool   `yaml:"exists"`
+	Count    *int    `yaml:"count"`
+	MinCount *int    `yaml:"min_count"`
+	Equals   *string `yaml:"equals"`
+	Regex    *string `yaml:"regex"`
+}
+
+// XMLResult is the outcome of one XML assertion.
+type XMLResult struct {
+	Assertion XMLAssertion
+	Ok        bool
+	Actual    string // what was selected: a node count or node texts
+	Err       string // set when the body or the expression cannot be evaluated
+}
+
+func (result XMLResult) passed() bool {
+	return result.Ok
+}
+
+// String describes the assertion as it appears in check output, e.g. `//target count >= 1`.
+func (assertion XMLAssertion) String() string {
+	return assertion.XPath + " " + assertion.comparison()
+}
+
+func (assertion XMLAssertion) comparison() string {
+	switch {
+	case assertion.Count != nil:
+		return fmt.Sprintf("count == %d", *assertion.Count)
+	case assertion.MinCount != nil:
+		return fmt.Sprintf("count >= %d", *assertion.MinCount)
+	case assertion.Equals != nil:
+		return fmt.Sprintf("equals %q", *assertion.Equals)
+	case assertion.Regex != nil:
+		return "matches /" + *assertion.Regex + "/"
+	case assertion.Exists != nil && !*assertion.Exists:
+		return "does not exist"
+	default:
+		return "exists"
+	}
+}
+
+// evaluateXML parses the body and runs every XML assertion against it.
+func evaluateXML(body string, assertions []XMLAssertion, namespaces map[string]string) (bool, []XMLResult) {
+	if len(assertions) == 0 {
+		return true, nil
+	}
+
+	document, parseErr := xmlquery.Parse(strings.NewReader(body))
+
+	ok := true
+	results := make([]XMLResult, 0, len(assertions))
+	for _, assertion := range assertions {
+		var result XMLResult
+		if parseErr != nil {
+			result = XMLResult{Assertion: assertion, Err: fmt.Sprintf("response is not valid XML: %v", parseErr)}
+		} else {
+			result = assertion.evaluate(document, namespaces)
+		}
+		ok = ok && result.Ok
+		results = append(results, result)
+	}
+	return ok, results
+}
+
+// evaluate selects the assertion's nodes from the parsed document and applies its comparison.
+func (assertion XMLAssertion) evaluate(document *xmlquery.Node, namespaces map[string]string) XMLResult {
+	result := XMLResult{Assertion: assertion}
+
+	if assertion.XPath == "" {
+		result.Err = "xpath is required"
+		return result
+	}
+	expr, err := xpath.CompileWithNS(assertion.XPath, namespaces)
+	if err != nil {
+		result.Err = fmt.Sprintf("invalid xpath: %v", err)
+		return result
+	}
+	nodes := xmlquery.QuerySelectorAll(document, expr)
+
+	switch {
+	case assertion.Count != nil:
+		result.Actual = nodeCount(len(nodes))
+		result.Ok = len(nodes) == *assertion.Count
+	case assertion.MinCount != nil:
+		result.Actual = nodeCount(len(nodes))
+		result.Ok = len(nodes) >= *assertion.MinCount
+	case assertion.Equals != nil, assertion.Regex != nil:
+		var re *regexp.Regexp
+		if assertion.Regex != nil {
+			if re, err = regexp.Compile(*assertion.Regex); err != nil {
+				result.Err = fmt.Sprintf("invalid regex: %v", err)
+				return result
+			}
+		}
+		texts := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			text := strings.TrimSpace(node.InnerText())
+			texts = append(texts, text)
+			if re != nil {
+				result.Ok = result.Ok || re.MatchString(text)
+			} else {
+				result.Ok = result.Ok || text == *assertion.Equals
+			}
+		}
+		result.Actual = summarizeSelection(texts)
+	case assertion.Exists != nil && !*assertion.Exists:
+		result.Actual = nodeCount(len(nodes))
+		result.Ok = len(nodes) == 0
+	default:
+		result.Actual = nodeCount(len(nodes))
+		result.Ok = len(nodes) > 0
+	}
+	return result
+}
+
+func nodeCount(n int) string {
+	if n == 1 {
+		return "1 node"
+	}
+	return fmt.Sprintf("%d nodes", n)
+}
+
+// xmlResultString formats one XML assertion outcome, including what was selected on failure.
+func xmlResultString(result XMLResult) string {
+	if result.Ok {
+		return fmt.Sprintf("Success: XML %s", result.Assertion)
+	}
+	if result.Err != "" {
+		return fmt.Sprintf("Failure: XML %s could not be evaluated: %s", result.Assertion, result.Err)
+	}
+	return fmt.Sprintf("Failure: XML %s, actual: %s", result.Assertion, result.Actual)
+}
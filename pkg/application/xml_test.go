@@ -0,0 +1,160 @@
+package application
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const sfxResponseXML = `<?xml version="1.0" encoding="UTF-8"?>
+<ctx_obj_set xmlns:ctx="info:ofi/fmt:xml:xsd:ctx">
+  <ctx_obj identifier="">
+    <ctx:context-object timestamp="2026-10-17T00:00:00Z">
+      <ctx:referent>
+        <ctx:metadata-by-val>
+          <ctx:format>info:ofi/fmt:xml:xsd:book</ctx:format>
+        </ctx:metadata-by-val>
+      </ctx:referent>
+    </ctx:context-object>
+    <ctx_obj_targets>
+      <target>
+        <target_name>OXFORD_SCHOLARSHIP_ONLINE</target_name>
+        <service_type>getFullTxt</service_type>
+      </target>
+      <target>
+        <target_name>ASK_A_LIBRARIAN</target_name>
+        <service_type>getWebService</service_type>
+      </target>
+    </ctx_obj_targets>
+  </ctx_obj>
+</ctx_obj_set>`
+
+var sfxNamespaces = map[string]string{"ctx": "info:ofi/fmt:xml:xsd:ctx"}
+
+func TestXMLAssertionsUnmarshalYAML(t *testing.T) {
+	const config = `
+xml_namespaces:
+  ctx: 'info:ofi/fmt:xml:xsd:ctx'
+expected_xml:
+  - xpath: '//ctx:context-object'
+  - xpath: '//ctx_obj_targets/target'
+    min_count: 1
+  - xpath: '//target/service_type'
+    equals: getFullTxt
+`
+	var app Application
+	require.NoError(t, yaml.Unmarshal([]byte(config), &app))
+	require.Len(t, app.ExpectedXML, 3)
+
+	assert.Equal(t, sfxNamespaces, app.XMLNamespaces)
+	assert.Equal(t, 1, *app.ExpectedXML[1].MinCount)
+	assert.Equal(t, "getFullTxt", *app.ExpectedXML[2].Equals)
+	assert.True(t, app.IsGet(), "XML assertions need the response body")
+}
+
+func TestEvaluateXML(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+	boolean := func(b bool) *bool { return &b }
+
+	var tests = []struct {
+		description string
+		assertion   XMLAssertion
+		namespaces  map[string]string
+		wantOk      bool
+		wantActual  string
+		wantErr     string
+	}{
+		{"Exists", XMLAssertion{XPath: "//ctx_obj_targets/target"}, nil, true, "2 nodes", ""},
+		{"Prefixed name resolves through namespaces", XMLAssertion{XPath: "//ctx:context-object/ctx:referent"}, sfxNamespaces, true, "1 node", ""},
+		{"Prefix bound to another namespace does not match", XMLAssertion{XPath: "//ctx:context-object"}, map[string]string{"ctx": "urn:other"}, false, "0 nodes", ""},
+		{"Unbound prefix matches the document's own prefix", XMLAssertion{XPath: "//ctx:context-object"}, nil, true, "1 node", ""},
+		{"Does not exist", XMLAssertion{XPath: "//error", Exists: boolean(false)}, nil, true, "0 nodes", ""},
+		{"Exact count", XMLAssertion{XPath: "//target", Count: num(2)}, nil, true, "2 nodes", ""},
+		{"Minimum count", XMLAssertion{XPath: "//target", MinCount: num(1)}, nil, true, "2 nodes", ""},
+		{"Minimum count fails", XMLAssertion{XPath: "//target[service_type='getHolding']", MinCount: num(1)}, nil, false, "0 nodes", ""},
+		{"Text equals on any node", XMLAssertion{XPath: "//target/service_type", Equals: str("getFullTxt")}, nil, true, `"getFullTxt", "getWebService"`, ""},
+		{"Text equals fails", XMLAssertion{XPath: "//target/service_type", Equals: str("getHolding")}, nil, false, `"getFullTxt", "getWebService"`, ""},
+		{"Text regex", XMLAssertion{XPath: "//ctx:format", Regex: str(`xsd:book$`)}, sfxNamespaces, true, `"info:ofi/fmt:xml:xsd:book"`, ""},
+		{"Attribute text", XMLAssertion{XPath: "//ctx:context-object/@timestamp", Regex: str(`^\d{4}-`)}, sfxNamespaces, true, `"2026-10-17T00:00:00Z"`, ""},
+		{"Text with no node", XMLAssertion{XPath: "//holding", Equals: str("x")}, nil, false, "no match", ""},
+		{"Invalid regex", XMLAssertion{XPath: "//target", Regex: str("(")}, nil, false, "", "invalid regex"},
+		{"Invalid XPath", XMLAssertion{XPath: "//target["}, nil, false, "", "invalid xpath"},
+		{"XPath required", XMLAssertion{}, nil, false, "", "xpath is required"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			ok, results := evaluateXML(sfxResponseXML, []XMLAssertion{test.assertion}, test.namespaces)
+			require.Len(t, results, 1)
+			assert.Equal(t, test.wantOk, ok)
+			assert.Equal(t, test.wantOk, results[0].Ok)
+			assert.Equal(t, test.wantActual, results[0].Actual)
+			if test.wantErr != "" {
+				assert.Contains(t, results[0].Err, test.wantErr)
+			} else {
+				assert.Empty(t, results[0].Err)
+			}
+		})
+	}
+}
+
+func TestEvaluateXML_InvalidBody(t *testing.T) {
+	ok, results := evaluateXML("<html><body>Service Unavailable", []XMLAssertion{{XPath: "//target"}}, nil)
+	assert.False(t, ok)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Err, "response is not valid XML")
+}
+
+func TestXMLResultString(t *testing.T) {
+	minTargets := 1
+	assertion := XMLAssertion{XPath: "//ctx_obj_targets/target", MinCount: &minTargets}
+
+	assert.Equal(t, `Success: XML //ctx_obj_targets/target count >= 1`,
+		xmlResultString(XMLResult{Assertion: assertion, Ok: true, Actual: "2 nodes"}))
+	assert.Equal(t, `Failure: XML //ctx_obj_targets/target count >= 1, actual: 0 nodes`,
+		xmlResultString(XMLResult{Assertion: assertion, Actual: "0 nodes"}))
+	assert.Equal(t, `Failure: XML //ctx_obj_targets/target count >= 1 could not be evaluated: response is not valid XML: EOF`,
+		xmlResultString(XMLResult{Assertion: assertion, Err: "response is not valid XML: EOF"}))
+}
+
+func TestGetStatus_XMLAssertions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = fmt.Fprint(w, sfxResponseXML)
+	}))
+	t.Cleanup(srv.Close)
+
+	minTargets := 1
+	fullText := "getFullTxt"
+	app := &Application{
+		Name:               "getit-ebook-sfx-api",
+		URL:                srv.URL,
+		ExpectedStatusCode: http.StatusOK,
+		Timeout:            2 * time.Second,
+		XMLNamespaces:      sfxNamespaces,
+		ExpectedXML: []XMLAssertion{
+			{XPath: "//ctx:context-object"},
+			{XPath: "//ctx_obj_targets/target", MinCount: &minTargets},
+			{XPath: "//target/service_type", Equals: &fullText},
+		},
+	}
+
+	status := app.GetStatus()
+
+	require.NotNil(t, status)
+	assert.True(t, status.StatusOk)
+	assert.True(t, status.StatusContentOk)
+	require.Len(t, status.XMLResults, 3)
+	assert.Equal(t, "Success: URL "+srv.URL+" resolved with 200\n"+
+		"Success: XML //ctx:context-object exists\n"+
+		"Success: XML //ctx_obj_targets/target count >= 1\n"+
+		`Success: XML //target/service_type equals "getFullTxt"`,
+		outputWithoutTimings(status))
+}
@@ -1,64 +1,135 @@
 package config
 
 import (
-	"errors"
-	"os"
-	"path/filepath"
 	"slices"
-	"strconv"
+	"time"
 
 	a "github.com/NYULibraries/aswa/pkg/application"
 	"gopkg.in/yaml.v3"
 )
 
-// Define whitelist using a map with empty struct as values.
-// Simulate a set using a map with empty structs as values, which take up zero bytes. This way, the lookup is both quick and memory-efficient
-var allowedConfigPaths = map[string]struct{}{
-	"config/dev.applications.yml":      {},
-	"config/primo_ve.applications.yml": {},
-	"config/prod.applications.yml":     {},
-	"config/saas.applications.yml":     {},
-}
-
+// EnvSkipWhitelistCheck disables the allowed roots check, e.g. for tests that load config from temporary directories.
 const EnvSkipWhitelistCheck = "SKIP_WHITELIST_CHECK"
 
+// DefaultTimeout is the timeout of a check that sets none itself, in defaults or in its group.
+// A timeout of 0s set explicitly means no timeout.
+const DefaultTimeout = 30 * time.Second
+
 // Config struct to replace environment variables
 type Config struct {
 	Applications []*a.Application
+	sources      []source // where each application is defined, for Lint
 }
 
-// Check if any required App field is empty
-func hasEmptyRequiredFields(app *a.Application) bool {
-	return app.Name == "" || app.URL == "" || app.ExpectedStatusCode == 0
+// rawConfig is the config file as written, before defaults and groups are applied to the applications.
+type rawConfig struct {
+	Include      []string             `yaml:"include"`
+	Defaults     yaml.Node            `yaml:"defaults"`
+	Groups       map[string]yaml.Node `yaml:"groups"`
+	Applications []yaml.Node          `yaml:"applications"`
 }
 
-// Loop through all categories and applications, check if any required field is empty
-func (cfg *Config) isConfigAnyRequiredFieldEmpty() bool {
-	return slices.ContainsFunc(cfg.Applications, hasEmptyRequiredFields)
+// resolve returns the applications with their effective settings: the defaults of the file that
+// defines the application, overridden by its group, overridden by the application itself. Maps such as headers and tls are
+// merged key by key; lists and other values are replaced. It returns the source of each
+// application it resolved.
+func (v *validator) resolve(raw rawConfig, sources []source) ([]*a.Application, []source) {
+	applications := make([]*a.Application, 0, len(raw.Applications))
+	resolved := make([]source, 0, len(raw.Applications))
+	for i, src := range sources {
+		node := &raw.Applications[i]
+		name := applicationName(node)
+
+		effective := src.defaults
+		if groupNode := mappingValue(node, "group"); groupNode != nil && groupNode.Value != "" {
+			group, ok := raw.Groups[groupNode.Value]
+			if !ok {
+				v.add(src.file, groupNode, name, "unknown group %q", groupNode.Value)
+				continue
+			}
+			effective = mergeNodes(effective, &group)
+		}
+		effective = mergeNodes(effective, node)
+
+		app := &a.Application{}
+		if err := effective.Decode(app); err != nil {
+			// Values of the wrong type were already reported where they are set.
+			continue
+		}
+		// An explicit timeout of 0s disables the timeout; only an unset one gets the default.
+		if timeout := mappingValue(effective, "timeout"); timeout == nil || timeout.Tag == "!!null" {
+			app.Timeout = DefaultTimeout
+		}
+		src.effective = effective
+		applications = append(applications, app)
+		resolved = append(resolved, src)
+	}
+	return applications, resolved
 }
 
-func loadConfig(yamlPath string) (*Config, error) {
-	skipCheck, _ := strconv.ParseBool(os.Getenv(EnvSkipWhitelistCheck))
-	if !skipCheck {
-		if _, ok := allowedConfigPaths[filepath.Clean(yamlPath)]; !ok {
-			return nil, errors.New("config file path is not allowed")
+// mergeNodes returns override laid over base without modifying either. Mappings are merged
+// key by key, recursively; anything else in override replaces base.
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	if base.Kind == 0 {
+		return override
+	}
+	if override.Kind == 0 {
+		return base
+	}
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	merged := *base
+	merged.Content = slices.Clone(base.Content)
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		j := mappingIndex(&merged, key.Value)
+		if j < 0 {
+			merged.Content = append(merged.Content, key, value)
+			continue
 		}
+		merged.Content[j+1] = mergeNodes(merged.Content[j+1], value)
 	}
-	data, err := os.ReadFile(yamlPath)
+	return &merged
+}
+
+// mappingIndex returns the index of key in a mapping node's content, or -1.
+func mappingIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// loadConfig loads the config file, directory or glob at yamlPath and the files they include.
+func loadConfig(yamlPath string) (*Config, error) {
+	policy, err := loadConfigPolicy()
 	if err != nil {
 		return nil, err
 	}
-
-	var config Config
-	err = yaml.Unmarshal(data, &config)
+	files, err := loadConfigFiles(policy, yamlPath, &yaml.Node{}, nil, map[string]bool{})
 	if err != nil {
 		return nil, err
 	}
-	if config.isConfigAnyRequiredFieldEmpty() {
-		return nil, errors.New("config file is missing one or more required fields: name, url, expected_status code")
+
+	v := &validator{}
+	for _, file := range files {
+		v.checkFile(file)
+	}
+	raw, sources := v.mergeConfigFiles(files)
+	applications, sources := v.resolve(raw, sources)
+	for i, app := range applications {
+		v.validateApplication(sources[i], app)
+	}
+	v.checkDuplicateNames(applications, sources)
+	if err := v.err(); err != nil {
+		return nil, err
 	}
 
-	return &config, nil
+	return &Config{Applications: applications, sources: sources}, nil
 }
 
 func NewConfig(yamlPath string) (*Config, error) {
@@ -2,11 +2,14 @@ package config
 
 import (
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	a "github.com/NYULibraries/aswa/pkg/application"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const configTestPath = "../../config/prod.applications.yml"
@@ -22,7 +25,7 @@ func TestNewConfig(t *testing.T) {
 		{"Valid saas config", "../../config/saas.applications.yml", ""},
 		{"Valid primo_ve config", "../../config/primo_ve.applications.yml", ""},
 		{"Valid testdata config", "../../testdata/expect_valid.yml", ""},
-		{"Missing required fields", "../../testdata/expect_invalid.yml", "config file is missing one or more required fields"},
+		{"Missing required fields", "../../testdata/expect_invalid.yml", "testdata/expect_invalid.yml:4:21: application \"specialcollections\": missing required field expected_status"},
 		{"Wrong type for timeout", "../../testdata/expect_timeout_wrong_type.yml", "cannot unmarshal !!int `600` into time.Duration"},
 		{"Nonexistent file in config dir", "../../config/does_not_exist.yml", "no such file or directory"},
 		{"Nonexistent config.yml", "../../config/config.yml", "no such file or directory"},
@@ -50,7 +53,7 @@ func testNewConfigFunc(path string, expectedErr string) func(*testing.T) {
 	}
 }
 
-func TestIsConfigAnyRequiredFieldEmpty(t *testing.T) {
+func TestMissingRequiredFields(t *testing.T) {
 	var tests = []struct {
 		description string
 		application *a.Application
@@ -70,14 +73,252 @@ func TestIsConfigAnyRequiredFieldEmpty(t *testing.T) {
 		{"Empty application", &a.Application{Name: "", URL: "", ExpectedStatusCode: http.StatusOK}, false},
 		{"Empty application", &a.Application{Name: "test", URL: "http://test.com", Timeout: time.Second}, false},
 		{"Empty application", &a.Application{Name: "", URL: "", Timeout: time.Second, ExpectedLocation: "test"}, false},
+		{"Valid DNS check", &a.Application{Name: "test", Type: a.CheckDNS, DNS: &a.DNSCheck{Name: "test.com"}}, true},
+		{"DNS check without a name to resolve", &a.Application{Name: "test", Type: a.CheckDNS, DNS: &a.DNSCheck{}}, false},
+		{"DNS check without a dns block", &a.Application{Name: "test", Type: a.CheckDNS, URL: "http://test.com"}, false},
+		{"Valid TCP check", &a.Application{Name: "test", Type: a.CheckTCP, TCP: &a.TCPCheck{Address: "test.com:210"}}, true},
+		{"TCP check without an address", &a.Application{Name: "test", Type: a.CheckTCP, TCP: &a.TCPCheck{}}, false},
+		{"Valid multi-step check", &a.Application{Name: "test", Steps: []a.Step{{URL: "http://test.com/login"}, {URL: "http://test.com/home"}}}, true},
+		{"Multi-step check with a step without a URL", &a.Application{Name: "test", Steps: []a.Step{{URL: "http://test.com/login"}, {Name: "home"}}}, false},
 	}
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			cfg := &Config{Applications: []*a.Application{test.application}}
-			// isConfigAnyRequiredFieldEmpty reports true exactly when an application is
-			// missing a required field, i.e. the inverse of a valid application.
-			assert.Equal(t, !test.valid, cfg.isConfigAnyRequiredFieldEmpty())
+			assert.Equal(t, test.valid, len(missingRequiredFields(test.application)) == 0)
+		})
+	}
+}
+
+// writeConfig writes a config file to a temporary directory and returns its path.
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	t.Setenv(EnvSkipWhitelistCheck, "true")
+	path := filepath.Join(t.TempDir(), "applications.yml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	return path
+}
+
+func TestNewConfig_DefaultsAndGroups(t *testing.T) {
+	path := writeConfig(t, `
+defaults:
+  timeout: 5s
+  max_redirects: 3
+  headers:
+    Accept: text/html
+  tls:
+    min_version: '1.2'
+groups:
+  cdn:
+    timeout: 2s
+    headers:
+      Accept: '*/*'
+  saas:
+    retries: 2
+    tls:
+      server_name: nyu.libguides.com
+applications:
+  - name: library
+    url: 'https://library.nyu.edu'
+    expected_status: 200
+  - name: bess
+    group: cdn
+    url: 'https://cdn.library.nyu.edu/bess-vue/app.min.js'
+    expected_status: 200
+    headers:
+      X-Check: aswa
+  - name: libguides
+    group: saas
+    url: 'https://guides.nyu.edu'
+    expected_status: 200
+    timeout: 10s
+    max_redirects: 5
+    tls: null
+`)
+
+	config, err := NewConfig(path)
+
+	require.NoError(t, err)
+	require.Len(t, config.Applications, 3)
+
+	library := config.Applications[0]
+	assert.Equal(t, 5*time.Second, library.Timeout)
+	assert.Equal(t, 3, library.MaxRedirects)
+	assert.Equal(t, map[string]string{"Accept": "text/html"}, library.Headers)
+	require.NotNil(t, library.TLS)
+	assert.Equal(t, "1.2", library.TLS.MinVersion)
+
+	bess := config.Applications[1]
+	assert.Equal(t, "cdn", bess.Group)
+	assert.Equal(t, 2*time.Second, bess.Timeout, "the group overrides the defaults")
+	assert.Equal(t, map[string]string{"Accept": "*/*", "X-Check": "aswa"}, bess.Headers, "headers are merged")
+	assert.Equal(t, 3, bess.MaxRedirects)
+
+	libguides := config.Applications[2]
+	assert.Equal(t, 10*time.Second, libguides.Timeout, "the application overrides its group")
+	assert.Equal(t, 5, libguides.MaxRedirects)
+	assert.Equal(t, 2, libguides.Retries)
+	assert.Nil(t, libguides.TLS, "null removes an inherited setting")
+}
+
+func TestNewConfig_DefaultTimeout(t *testing.T) {
+	path := writeConfig(t, `
+applications:
+  - name: library
+    url: 'https://library.nyu.edu'
+    expected_status: 200
+  - name: specialcollections
+    url: 'https://specialcollections.library.nyu.edu/search/'
+    expected_status: 200
+    timeout: 600ms
+  - name: ezproxy-export
+    url: 'https://ezproxy.library.nyu.edu/export'
+    expected_status: 200
+    timeout: 0s
+  - name: ezproxy-login
+    url: 'https://ezproxy.library.nyu.edu/login'
+    expected_status: 200
+    timeout: null
+`)
+
+	config, err := NewConfig(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTimeout, config.Applications[0].Timeout)
+	assert.Equal(t, 600*time.Millisecond, config.Applications[1].Timeout)
+	assert.Equal(t, time.Duration(0), config.Applications[2].Timeout, "an explicit 0s disables the timeout")
+	assert.Equal(t, DefaultTimeout, config.Applications[3].Timeout)
+}
+
+func TestNewConfig_UnknownGroup(t *testing.T) {
+	path := writeConfig(t, `
+groups:
+  cdn:
+    timeout: 2s
+applications:
+  - name: bess
+    group: cnd
+    url: 'https://cdn.library.nyu.edu/bess-vue/app.min.js'
+    expected_status: 200
+`)
+
+	_, err := NewConfig(path)
+
+	assert.EqualError(t, err, "config file is invalid:\n"+path+`:7:12: application "bess": unknown group "cnd"`)
+}
+
+// Required fields are checked on the effective settings, so defaults can supply them.
+func TestNewConfig_RequiredFieldsAfterDefaults(t *testing.T) {
+	path := writeConfig(t, `
+defaults:
+  expected_status: 200
+applications:
+  - name: library
+    url: 'https://library.nyu.edu'
+  - name: missing-url
+`)
+
+	_, err := NewConfig(path)
+
+	assert.EqualError(t, err, "config file is invalid:\n"+path+`:7:5: application "missing-url": missing required field url`)
+}
+
+// writeConfigDir writes config files, keyed by path relative to a temporary directory, and returns the directory.
+func writeConfigDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+	return dir
+}
+
+func application(name string) string {
+	return "\n  - name: " + name + "\n    url: 'https://" + name + ".library.nyu.edu'\n    expected_status: 200\n"
+}
+
+func applicationNames(config *Config) []string {
+	var names []string
+	for _, app := range config.Applications {
+		names = append(names, app.Name)
+	}
+	return names
+}
+
+func TestNewConfig_MultipleFiles(t *testing.T) {
+	t.Setenv(EnvSkipWhitelistCheck, "true")
+	dir := writeConfigDir(t, map[string]string{
+		"prod.d/discovery.yml": "include: ['../shared/saas.yml']\ndefaults:\n  timeout: 3s\napplications:" + application("primo") + application("getit"),
+		"prod.d/archives.yaml": "defaults:\n  timeout: 5s\napplications:" + application("specialcollections"),
+		"prod.d/notes.txt":     "not a config file",
+		"shared/saas.yml":      "include: ['saas.yml']\napplications:" + application("libguides"),
+	})
+
+	var tests = []struct {
+		description string
+		path        string
+		want        []string
+	}{
+		{"Directory", filepath.Join(dir, "prod.d"), []string{"specialcollections", "primo", "getit", "libguides"}},
+		{"Glob", filepath.Join(dir, "prod.d", "disc*.yml"), []string{"primo", "getit", "libguides"}},
+		{"File with includes", filepath.Join(dir, "shared", "saas.yml"), []string{"libguides"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			config, err := NewConfig(test.path)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.want, applicationNames(config))
+		})
+	}
+
+	config, err := NewConfig(filepath.Join(dir, "prod.d"))
+	require.NoError(t, err)
+	var timeouts []time.Duration
+	for _, app := range config.Applications {
+		timeouts = append(timeouts, app.Timeout)
+	}
+	assert.Equal(t, []time.Duration{5 * time.Second, 3 * time.Second, 3 * time.Second, 3 * time.Second}, timeouts,
+		"defaults apply to their own file and the files it includes, not to the other files")
+
+	config, err = NewConfig(filepath.Join(dir, "shared", "saas.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTimeout, config.Applications[0].Timeout, "an included file loaded by itself has no defaults")
+}
+
+func TestNewConfig_MultipleFilesErrors(t *testing.T) {
+	t.Setenv(EnvSkipWhitelistCheck, "true")
+	dir := writeConfigDir(t, map[string]string{
+		"prod.d/a.yml":    "groups:\n  cdn:\n    timeout: 2s\napplications:" + application("bess") + application("primo"),
+		"prod.d/b.yml":    "groups:\n  cdn:\n    timeout: 3s\napplications:" + application("primo") + application("bess"),
+		"names.d/a.yml":   "applications:" + application("bess") + application("primo"),
+		"names.d/b.yml":   "applications:" + application("getit") + application("primo"),
+		"dup.d/a.yml":     "applications:" + application("bess") + application("bess"),
+		"missing.d/a.yml": "include: ['b.yml']\napplications:" + application("bess"),
+		"empty.d/a.json":  "{}",
+	})
+	first := filepath.Join(dir, "prod.d", "a.yml")
+	second := filepath.Join(dir, "prod.d", "b.yml")
+
+	var tests = []struct {
+		description string
+		path        string
+		expectedErr string
+	}{
+		{"Duplicate groups", filepath.Join(dir, "prod.d"), second + `:3:5: duplicate group "cdn", first defined in ` + first + ":3:5"},
+		{"Duplicate names across files", filepath.Join(dir, "names.d", "*.yml"), filepath.Join(dir, "names.d", "b.yml") + `:6:11: application "primo": duplicate application name, first defined in ` + filepath.Join(dir, "names.d", "a.yml") + ":6:11"},
+		{"Duplicate names in one file", filepath.Join(dir, "dup.d"), filepath.Join(dir, "dup.d", "a.yml") + `:6:11: application "bess": duplicate application name, first defined in ` + filepath.Join(dir, "dup.d", "a.yml") + ":2:11"},
+		{"Missing include", filepath.Join(dir, "missing.d"), "no such file or directory"},
+		{"Directory without config files", filepath.Join(dir, "empty.d"), "no config files in directory"},
+		{"Glob without matches", filepath.Join(dir, "prod.d", "*.yaml"), "no config files match"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := NewConfig(test.path)
+			assert.ErrorContains(t, err, test.expectedErr)
 		})
 	}
 }
@@ -3,17 +3,45 @@ package config
 import (
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 )
 
 // Constants for environment variables
 const (
 	EnvClusterInfo               = "CLUSTER_INFO"
+	EnvConfigAllowedRoots        = "CONFIG_ALLOWED_ROOTS"
+	EnvConfigChecksums           = "CONFIG_CHECKSUMS"
+	EnvConfigPublicKey           = "CONFIG_PUBLIC_KEY"
+	EnvDriftStatePath            = "DRIFT_STATE_PATH"
+	EnvMaxConcurrency            = "MAX_CONCURRENCY"
+	EnvMaxConcurrencyPerHost     = "MAX_CONCURRENCY_PER_HOST"
 	EnvName                      = "ENV"
 	EnvPromAggregationGatewayUrl = "PROM_AGGREGATION_GATEWAY_URL"
 	EnvSlackWebhookUrl           = "SLACK_WEBHOOK_URL"
 	EnvYamlPath                  = "YAML_PATH"
 )
 
+// DefaultConfigAllowedRoot is the directory config files are loaded from when CONFIG_ALLOWED_ROOTS is not set
+const DefaultConfigAllowedRoot = "config"
+
+// DefaultDriftStatePath is the state file of content drift baselines used when DRIFT_STATE_PATH is not set.
+// It is on the /var/lib/aswa volume of the container image, so baselines outlive the container.
+const DefaultDriftStatePath = "/var/lib/aswa/drift_baselines.json"
+
+// Defaults for the concurrency limits used when running synthetic checks
+const (
+	DefaultMaxConcurrency        = 8
+	DefaultMaxConcurrencyPerHost = 2
+)
+
+// Commands run by aswa when they are the first argument, instead of the check of the application named by it.
+// Application names cannot be one of them, see GetCmdArg.
+const (
+	CommandValidate    = "validate"
+	CommandAcceptDrift = "accept-drift"
+)
+
 // GetClusterInfo retrieves the cluster info from environment variables.
 func GetClusterInfo() string {
 	clusterInfo := os.Getenv(EnvClusterInfo)
@@ -31,6 +59,40 @@ func GetCmdArg() string {
 	return os.Args[1]
 }
 
+// GetConfigAllowedRoots retrieves the directories config files may be loaded from, separated like PATH,
+// defaults to DefaultConfigAllowedRoot if not set
+func GetConfigAllowedRoots() []string {
+	var roots []string
+	for _, root := range filepath.SplitList(os.Getenv(EnvConfigAllowedRoots)) {
+		if root != "" {
+			roots = append(roots, root)
+		}
+	}
+	if len(roots) == 0 {
+		return []string{DefaultConfigAllowedRoot}
+	}
+	return roots
+}
+
+// GetConfigChecksumsPath retrieves the path of the checksum file config files are verified against, if any.
+func GetConfigChecksumsPath() string {
+	return os.Getenv(EnvConfigChecksums)
+}
+
+// GetConfigPublicKeyPath retrieves the path of the public key config file signatures are verified with, if any.
+func GetConfigPublicKeyPath() string {
+	return os.Getenv(EnvConfigPublicKey)
+}
+
+// GetDriftStatePath retrieves the path of the drift baselines state file, defaults to DefaultDriftStatePath if not set
+func GetDriftStatePath() string {
+	path := os.Getenv(EnvDriftStatePath)
+	if path == "" {
+		return DefaultDriftStatePath
+	}
+	return path
+}
+
 // GetEnvironmentName retrieves the environment name from environment variables, defaults to 'dev' if not set
 func GetEnvironmentName() string {
 	env := os.Getenv(EnvName)
@@ -40,6 +102,31 @@ func GetEnvironmentName() string {
 	return env
 }
 
+// GetMaxConcurrency retrieves the maximum number of checks to run at once, defaults to DefaultMaxConcurrency if not set or invalid
+func GetMaxConcurrency() int {
+	return getPositiveIntEnv(EnvMaxConcurrency, DefaultMaxConcurrency)
+}
+
+// GetMaxConcurrencyPerHost retrieves the maximum number of checks to run at once against a single host,
+// defaults to DefaultMaxConcurrencyPerHost if not set or invalid
+func GetMaxConcurrencyPerHost() int {
+	return getPositiveIntEnv(EnvMaxConcurrencyPerHost, DefaultMaxConcurrencyPerHost)
+}
+
+// getPositiveIntEnv parses a positive integer from the given environment variable, falling back to def.
+func getPositiveIntEnv(envVar string, def int) int {
+	raw := os.Getenv(envVar)
+	if raw == "" {
+		return def
+	}
+	val, err := strconv.Atoi(raw)
+	if err != nil || val < 1 {
+		log.Printf("%s must be a positive integer, got %q; using default %d", envVar, raw, def)
+		return def
+	}
+	return val
+}
+
 // GetPromAggregationgatewayUrl retrieves the pag url from environment variables.
 func GetPromAggregationgatewayUrl() string {
 	promAggregationGatewayUrl := os.Getenv(EnvPromAggregationGatewayUrl)
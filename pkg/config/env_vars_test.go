@@ -167,3 +167,100 @@ func TestGetEnvironmentName(t *testing.T) {
 		})
 	}
 }
+
+func TestGetDriftStatePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		statePath string
+		want      string
+	}{
+		{"DriftStatePath is set", "/var/lib/aswa/drift.json", "/var/lib/aswa/drift.json"},
+		{"DriftStatePath is not set", "", DefaultDriftStatePath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			t.Setenv(EnvDriftStatePath, tt.statePath)
+
+			got := GetDriftStatePath()
+
+			assert.Equal(t, tt.want, got, "GetDriftStatePath() should return correct state file path")
+
+		})
+	}
+}
+
+func TestGetMaxConcurrency(t *testing.T) {
+	tests := []struct {
+		name              string
+		envMaxConcurrency string
+		want              int
+		wantLogMessage    string
+	}{
+		{"EnvMaxConcurrency is set", "4", 4, ""},
+		{"EnvMaxConcurrency is not set", "", DefaultMaxConcurrency, ""},
+		{"EnvMaxConcurrency is not a number", "many", DefaultMaxConcurrency, "MAX_CONCURRENCY must be a positive integer, got \"many\"; using default 8\n"},
+		{"EnvMaxConcurrency is zero", "0", DefaultMaxConcurrency, "MAX_CONCURRENCY must be a positive integer, got \"0\"; using default 8\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			t.Setenv(EnvMaxConcurrency, tt.envMaxConcurrency)
+
+			var got int
+			gotLogMessage := CaptureOutput(func() { got = GetMaxConcurrency() })
+
+			assert.Equal(t, tt.want, got, "GetMaxConcurrency() should return correct concurrency limit")
+			assert.Equal(t, tt.wantLogMessage, gotLogMessage)
+		})
+	}
+}
+
+func TestGetMaxConcurrencyPerHost(t *testing.T) {
+	tests := []struct {
+		name                     string
+		envMaxConcurrencyPerHost string
+		want                     int
+	}{
+		{"EnvMaxConcurrencyPerHost is set", "1", 1},
+		{"EnvMaxConcurrencyPerHost is not set", "", DefaultMaxConcurrencyPerHost},
+		{"EnvMaxConcurrencyPerHost is negative", "-3", DefaultMaxConcurrencyPerHost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			t.Setenv(EnvMaxConcurrencyPerHost, tt.envMaxConcurrencyPerHost)
+
+			got := GetMaxConcurrencyPerHost()
+
+			assert.Equal(t, tt.want, got, "GetMaxConcurrencyPerHost() should return correct per-host concurrency limit")
+		})
+	}
+}
+
+func TestGetConfigAllowedRoots(t *testing.T) {
+	tests := []struct {
+		name  string
+		roots string
+		want  []string
+	}{
+		{"ConfigAllowedRoots is set", "/etc/aswa" + string(os.PathListSeparator) + "config", []string{"/etc/aswa", "config"}},
+		{"ConfigAllowedRoots has empty entries", string(os.PathListSeparator) + "config", []string{"config"}},
+		{"ConfigAllowedRoots is not set", "", []string{DefaultConfigAllowedRoot}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			t.Setenv(EnvConfigAllowedRoots, tt.roots)
+
+			got := GetConfigAllowedRoots()
+
+			assert.Equal(t, tt.want, got, "GetConfigAllowedRoots() should return correct roots")
+
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	a "github.com/NYULibraries/aswa/pkg/application"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is one config file as loaded, before it is merged with the others.
+type configFile struct {
+	path     string
+	root     yaml.Node
+	raw      rawConfig
+	defaults *yaml.Node // the file's own defaults laid over those of the file that included it
+}
+
+// expandConfigPath returns the config files a path names: the file itself, the .yml and .yaml
+// files directly inside a directory, or the files matching a glob, in lexical order.
+func expandConfigPath(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		var paths []string
+		for _, pattern := range []string{"*.yml", "*.yaml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, matches...)
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no config files in directory %s", path)
+		}
+		slices.Sort(paths)
+		return paths, nil
+	case err == nil:
+		return []string{path}, nil
+	case hasGlobMeta(path):
+		paths, globErr := filepath.Glob(path)
+		if globErr != nil {
+			return nil, globErr
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no config files match %s", path)
+		}
+		return paths, nil
+	default:
+		return nil, err
+	}
+}
+
+func hasGlobMeta(path string) bool {
+	return slices.ContainsFunc([]rune(path), func(r rune) bool { return r == '*' || r == '?' || r == '[' })
+}
+
+// loadConfigFiles reads the files path names and, recursively, the files they include, checking
+// each against the policy. Include paths are relative to the including file, and the included
+// files inherit its defaults, given as inherited. A file is only read once, however often and
+// through whichever links it is included, and keeps the defaults of where it was first loaded.
+func loadConfigFiles(policy *configPolicy, path string, inherited *yaml.Node, files []configFile, seen map[string]bool) ([]configFile, error) {
+	paths, err := expandConfigPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		resolved, err := policy.check(path)
+		if err != nil {
+			return nil, err
+		}
+		if seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+
+		data, err := os.ReadFile(resolved)
+		if err != nil {
+			return nil, err
+		}
+		if err := policy.verify(path, resolved, data); err != nil {
+			return nil, err
+		}
+		file := configFile{path: path}
+		if err := yaml.Unmarshal(data, &file.root); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if content := documentContent(&file.root); content != nil {
+			if err := content.Decode(&file.raw); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		file.defaults = mergeNodes(inherited, &file.raw.Defaults)
+		files = append(files, file)
+
+		for _, include := range file.raw.Include {
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(path), include)
+			}
+			if files, err = loadConfigFiles(policy, include, file.defaults, files, seen); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// mergeConfigFiles combines loaded files into one config. Each group may be defined only once.
+// sources holds where each application is defined and the defaults that apply to it.
+func (v *validator) mergeConfigFiles(files []configFile) (merged rawConfig, sources []source) {
+	merged.Groups = map[string]yaml.Node{}
+	groupSources := map[string]source{}
+	for _, file := range files {
+		for _, name := range slices.Sorted(maps.Keys(file.raw.Groups)) {
+			group := file.raw.Groups[name]
+			if first, ok := groupSources[name]; ok {
+				v.add(file.path, &group, "", "duplicate group %q, first defined in %s", name, position(first))
+				continue
+			}
+			merged.Groups[name] = group
+			groupSources[name] = source{file: file.path, node: &group}
+		}
+		for i := range file.raw.Applications {
+			merged.Applications = append(merged.Applications, file.raw.Applications[i])
+			sources = append(sources, source{file: file.path, node: &file.raw.Applications[i], defaults: file.defaults})
+		}
+	}
+	return merged, sources
+}
+
+// checkDuplicateNames reports every application name used more than once, with where it was first used.
+func (v *validator) checkDuplicateNames(applications []*a.Application, sources []source) {
+	first := map[string]source{}
+	for i, app := range applications {
+		if app.Name == "" {
+			continue
+		}
+		if firstSource, ok := first[app.Name]; ok {
+			v.add(sources[i].file, locate(sources[i].node, "name"), app.Name, "duplicate application name, first defined in %s", position(firstSource))
+			continue
+		}
+		first[app.Name] = source{file: sources[i].file, node: locate(sources[i].node, "name")}
+	}
+}
+
+// position formats where a node is as file:line:column.
+func position(source source) string {
+	return fmt.Sprintf("%s:%d:%d", source.file, source.node.Line, source.node.Column)
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+
+	a "github.com/NYULibraries/aswa/pkg/application"
+)
+
+// largePageBytes is the body size above which including the actual content in failure output is
+// flagged, since the whole page would be posted with every failure.
+const largePageBytes = 64 << 10
+
+// Lint returns the settings of a valid config that are likely mistakes. pageSizes holds the body
+// size of each application's page where it is known without a request, i.e. from drift baselines,
+// so the page size is only checked for applications with a recorded baseline.
+func (cfg *Config) Lint(pageSizes map[string]int) []Problem {
+	v := &validator{}
+	checkedURLs := map[string]string{}
+	for i, app := range cfg.Applications {
+		c := checker{v: v, source: cfg.sources[i], name: app.Name}
+
+		if timeout := mappingValue(c.source.effective, "timeout"); timeout == nil || timeout.Tag == "!!null" {
+			c.at("timeout", "no timeout set by the application, its group or defaults, the default %s applies", DefaultTimeout)
+		}
+
+		if app.URL != "" && app.Type != a.CheckDNS && app.Type != a.CheckTCP && len(app.Steps) == 0 {
+			method := app.Method
+			if method == "" {
+				method = http.MethodGet
+			}
+			key := method + " " + app.URL
+			if first, ok := checkedURLs[key]; ok {
+				c.at("url", "url %s is also checked by application %q", app.URL, first)
+			} else {
+				checkedURLs[key] = app.Name
+			}
+		}
+
+		if app.ExpectedLocation != "" && app.ExpectedStatusCode != 0 && !isRedirectStatus(app.ExpectedStatusCode) {
+			c.at("expected_location", "expected_location is compared with the Location header of a redirect, but expected_status is %d", app.ExpectedStatusCode)
+		}
+		for j, step := range app.Steps {
+			if step.ExpectedLocation != "" && step.ExpectedStatusCode != 0 && !isRedirectStatus(step.ExpectedStatusCode) {
+				c.at(fmt.Sprintf("steps[%d].expected_location", j), "expected_location is compared with the Location header of a redirect, but expected_status is %d", step.ExpectedStatusCode)
+			}
+		}
+
+		if app.IncludeActualContentOnFailure {
+			switch size, known := pageSizes[app.Name]; {
+			case len(app.ExpectedContent) == 0:
+				c.at("include_actual_content_on_failure", "include_actual_content_on_failure has no effect without expected_content")
+			case known && size > largePageBytes:
+				c.at("include_actual_content_on_failure", "include_actual_content_on_failure is set on a page of %d KiB, every failure would include all of it", size>>10)
+			}
+		}
+	}
+	return v.problems
+}
+
+// UnsizedPages returns the applications whose page size Lint could not check: those including the
+// actual content on failure with no size in pageSizes.
+func (cfg *Config) UnsizedPages(pageSizes map[string]int) []string {
+	var names []string
+	for _, app := range cfg.Applications {
+		if _, known := pageSizes[app.Name]; app.IncludeActualContentOnFailure && len(app.ExpectedContent) > 0 && !known {
+			names = append(names, app.Name)
+		}
+	}
+	return names
+}
+
+func isRedirectStatus(status int) bool {
+	return status >= 300 && status <= 399
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigLint(t *testing.T) {
+	path := writeConfig(t, `
+groups:
+  discovery:
+    timeout: 10s
+applications:
+  - name: library
+    url: 'https://library.nyu.edu'
+    expected_status: 200
+    expected_location: 'https://library.nyu.edu/'
+  - name: library-home
+    group: discovery
+    url: 'https://library.nyu.edu'
+    expected_status: 200
+  - name: library-search
+    url: 'https://library.nyu.edu'
+    expected_status: 200
+    method: POST
+    timeout: 5s
+  - name: primo-ve
+    group: discovery
+    url: 'https://search.library.nyu.edu/discovery/search?vid=01NYU_INST:NYU'
+    expected_status: 200
+    expected_content: 'NYU Libraries'
+    include_actual_content_on_failure: true
+  - name: illiad
+    timeout: 5s
+    include_actual_content_on_failure: true
+    steps:
+      - url: 'https://ill.library.nyu.edu/illiad/logon.html'
+        expected_status: 200
+        expected_location: 'https://ill.library.nyu.edu/illiad/home.html'
+`)
+
+	config, err := NewConfig(path)
+	require.NoError(t, err)
+
+	var problems []string
+	for _, problem := range config.Lint(map[string]int{"primo-ve": 512 << 10, "illiad": 2 << 10}) {
+		problems = append(problems, problem.String())
+	}
+	assert.Equal(t, []string{
+		path + `:6:5: application "library": no timeout set by the application, its group or defaults, the default 30s applies`,
+		path + `:9:24: application "library": expected_location is compared with the Location header of a redirect, but expected_status is 200`,
+		path + `:12:10: application "library-home": url https://library.nyu.edu is also checked by application "library"`,
+		path + `:24:40: application "primo-ve": include_actual_content_on_failure is set on a page of 512 KiB, every failure would include all of it`,
+		path + `:31:28: application "illiad": expected_location is compared with the Location header of a redirect, but expected_status is 200`,
+		path + `:27:40: application "illiad": include_actual_content_on_failure has no effect without expected_content`,
+	}, problems)
+}
+
+func TestConfigLint_NoWarnings(t *testing.T) {
+	path := writeConfig(t, `
+defaults:
+  timeout: 10s
+applications:
+  - name: library-nyu-edu
+    url: 'http://library.nyu.edu'
+    expected_status: 301
+    expected_location: 'https://library.nyu.edu'
+  - name: primo-ve
+    url: 'https://search.library.nyu.edu/discovery/search?vid=01NYU_INST:NYU'
+    expected_status: 200
+    expected_content: 'NYU Libraries'
+    include_actual_content_on_failure: true
+`)
+
+	config, err := NewConfig(path)
+	require.NoError(t, err)
+
+	assert.Empty(t, config.Lint(map[string]int{"primo-ve": 16 << 10}))
+}
+
+func TestConfigUnsizedPages(t *testing.T) {
+	path := writeConfig(t, `
+defaults:
+  timeout: 10s
+applications:
+  - name: primo-ve
+    url: 'https://search.library.nyu.edu/discovery/search?vid=01NYU_INST:NYU'
+    expected_status: 200
+    expected_content: 'NYU Libraries'
+    include_actual_content_on_failure: true
+  - name: primo-ve-search
+    url: 'https://search.library.nyu.edu/'
+    expected_status: 200
+    expected_content: 'NYU Libraries'
+    include_actual_content_on_failure: true
+  - name: library
+    url: 'https://library.nyu.edu'
+    expected_status: 200
+    expected_content: 'NYU Libraries'
+`)
+
+	config, err := NewConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"primo-ve-search"}, config.UnsizedPages(map[string]int{"primo-ve": 16 << 10}))
+	assert.Empty(t, config.UnsizedPages(map[string]int{"primo-ve": 16 << 10, "primo-ve-search": 16 << 10}))
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// signatureSuffix is appended to a config file's path to find its detached signature.
+const signatureSuffix = ".sig"
+
+// configPolicy decides which config files may be loaded: files inside one of the allowed roots
+// and, when configured, files whose SHA-256 is listed in a checksum file or that carry a
+// detached Ed25519 signature. Paths are resolved, following symlinks and "..", before they are
+// compared, so a link inside a root cannot point a file outside it.
+type configPolicy struct {
+	roots     []string // resolved, absolute; nil when SKIP_WHITELIST_CHECK is set
+	checksums map[string]string
+	publicKey ed25519.PublicKey
+}
+
+// loadConfigPolicy builds the policy from CONFIG_ALLOWED_ROOTS, CONFIG_CHECKSUMS and CONFIG_PUBLIC_KEY.
+func loadConfigPolicy() (*configPolicy, error) {
+	policy := &configPolicy{}
+
+	if skipCheck, _ := strconv.ParseBool(os.Getenv(EnvSkipWhitelistCheck)); skipCheck {
+		log.Printf("%s is set, config files are loaded from any directory", EnvSkipWhitelistCheck)
+	} else {
+		for _, root := range GetConfigAllowedRoots() {
+			resolved, err := resolvePath(root)
+			if err != nil {
+				return nil, fmt.Errorf("allowed config root %s: %w", root, err)
+			}
+			policy.roots = append(policy.roots, resolved)
+		}
+	}
+
+	if path := GetConfigChecksumsPath(); path != "" {
+		checksums, err := loadChecksums(path)
+		if err != nil {
+			return nil, err
+		}
+		policy.checksums = checksums
+	}
+
+	if path := GetConfigPublicKeyPath(); path != "" {
+		publicKey, err := loadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		policy.publicKey = publicKey
+	}
+	return policy, nil
+}
+
+// resolvePath returns the absolute path with symlinks and ".." resolved.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// check returns the resolved path of a config file if the policy allows loading it.
+func (policy *configPolicy) check(path string) (string, error) {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	if policy.roots != nil && !policy.withinRoots(resolved) {
+		return "", fmt.Errorf("config file path %s is not allowed: %s is outside the allowed roots", path, resolved)
+	}
+	return resolved, nil
+}
+
+func (policy *configPolicy) withinRoots(resolved string) bool {
+	for _, root := range policy.roots {
+		rel, err := filepath.Rel(root, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// verify checks a config file's contents against the checksum file and signature key, if configured.
+func (policy *configPolicy) verify(path, resolved string, data []byte) error {
+	if policy.checksums != nil {
+		expected, ok := policy.checksums[resolved]
+		if !ok {
+			return fmt.Errorf("config file %s is not listed in %s", path, GetConfigChecksumsPath())
+		}
+		sum := sha256.Sum256(data)
+		if actual := hex.EncodeToString(sum[:]); actual != expected {
+			return fmt.Errorf("config file %s has checksum %s, expected %s", path, actual, expected)
+		}
+	}
+
+	if policy.publicKey != nil {
+		encoded, err := os.ReadFile(resolved + signatureSuffix)
+		if err != nil {
+			return fmt.Errorf("config file %s has no signature: %w", path, err)
+		}
+		signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+		if err != nil {
+			return fmt.Errorf("config file %s signature is not base64: %w", path, err)
+		}
+		if !ed25519.Verify(policy.publicKey, data, signature) {
+			return fmt.Errorf("config file %s signature does not verify", path)
+		}
+	}
+	return nil
+}
+
+// loadChecksums reads a checksum file in the format of sha256sum: a hex SHA-256 and a path per line,
+// relative to the checksum file. It returns the checksums by resolved path.
+func loadChecksums(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config checksums: %w", err)
+	}
+
+	checksums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		sum, file, found := strings.Cut(text, " ")
+		file = strings.TrimPrefix(strings.TrimSpace(file), "*")
+		if decoded, err := hex.DecodeString(sum); !found || err != nil || len(decoded) != sha256.Size || file == "" {
+			return nil, fmt.Errorf("%s:%d: expected a SHA-256 and a path", path, line)
+		}
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+		resolved, err := resolvePath(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		checksums[resolved] = strings.ToLower(sum)
+	}
+	return checksums, scanner.Err()
+}
+
+// loadPublicKey reads a PEM-encoded Ed25519 public key, as written by `openssl pkey -pubout`.
+func loadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config public key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("config public key %s is not PEM encoded", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("config public key %s: %w", path, err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("config public key " + path + " is not an Ed25519 key")
+	}
+	return publicKey, nil
+}
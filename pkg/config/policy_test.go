@@ -0,0 +1,145 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const policyTestConfig = "applications:\n  - name: library\n    url: 'https://library.nyu.edu'\n    expected_status: 200\n"
+
+// chdirConfigTree creates a working directory with config/prod.applications.yml, config/prod.d/discovery.yml,
+// a file outside config and a symlink inside config pointing to it.
+func chdirConfigTree(t *testing.T) string {
+	t.Helper()
+	t.Setenv(EnvSkipWhitelistCheck, "false")
+	dir := writeConfigDir(t, map[string]string{
+		"config/prod.applications.yml": policyTestConfig,
+		"config/prod.d/discovery.yml":  policyTestConfig,
+		"secrets/applications.yml":     policyTestConfig,
+	})
+	require.NoError(t, os.Symlink(filepath.Join(dir, "secrets", "applications.yml"), filepath.Join(dir, "config", "linked.yml")))
+	t.Chdir(dir)
+	return dir
+}
+
+func TestConfigPolicyAllowedRoots(t *testing.T) {
+	chdirConfigTree(t)
+
+	var tests = []struct {
+		description string
+		roots       string
+		path        string
+		expectedErr string
+	}{
+		{"File in the default root", "", "config/prod.applications.yml", ""},
+		{"File in a subdirectory", "", "config/prod.d/discovery.yml", ""},
+		{"Directory in the root", "", "config/prod.d", ""},
+		{"Traversal out of the root", "", "config/prod.d/../../secrets/applications.yml", "is outside the allowed roots"},
+		{"Symlink out of the root", "", "config/linked.yml", "is outside the allowed roots"},
+		{"File outside the root", "", "secrets/applications.yml", "config file path secrets/applications.yml is not allowed"},
+		{"Second root", "config" + string(os.PathListSeparator) + "secrets", "secrets/applications.yml", ""},
+		{"Narrower root", "config/prod.d", "config/prod.applications.yml", "is outside the allowed roots"},
+		{"Root that does not exist", "conf", "config/prod.applications.yml", "allowed config root conf"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			t.Setenv(EnvConfigAllowedRoots, test.roots)
+
+			_, err := NewConfig(test.path)
+
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.expectedErr)
+			}
+		})
+	}
+}
+
+func TestConfigPolicySkipWhitelistCheck(t *testing.T) {
+	chdirConfigTree(t)
+	t.Setenv(EnvSkipWhitelistCheck, "true")
+
+	_, err := NewConfig("secrets/applications.yml")
+
+	assert.NoError(t, err)
+}
+
+func TestConfigPolicyChecksums(t *testing.T) {
+	dir := chdirConfigTree(t)
+	sum := sha256.Sum256([]byte(policyTestConfig))
+	checksums := filepath.Join(dir, "config", "SHA256SUMS")
+	require.NoError(t, os.WriteFile(checksums, []byte(
+		"# generated with sha256sum\n"+
+			hex.EncodeToString(sum[:])+"  prod.applications.yml\n"+
+			hex.EncodeToString(make([]byte, sha256.Size))+" *prod.d/discovery.yml\n"), 0o644))
+	t.Setenv(EnvConfigChecksums, checksums)
+
+	_, err := NewConfig("config/prod.applications.yml")
+	assert.NoError(t, err)
+
+	_, err = NewConfig("config/prod.d/discovery.yml")
+	assert.ErrorContains(t, err, "config file config/prod.d/discovery.yml has checksum "+hex.EncodeToString(sum[:])+", expected 0000")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config", "new.yml"), []byte(policyTestConfig), 0o644))
+	_, err = NewConfig("config/new.yml")
+	assert.EqualError(t, err, "config file config/new.yml is not listed in "+checksums)
+}
+
+func TestLoadChecksums_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "SHA256SUMS")
+	require.NoError(t, os.WriteFile(path, []byte("abc123  prod.applications.yml\n"), 0o644))
+
+	_, err := loadChecksums(path)
+
+	assert.EqualError(t, err, path+":1: expected a SHA-256 and a path")
+}
+
+func TestConfigPolicySignatures(t *testing.T) {
+	dir := chdirConfigTree(t)
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, "config.pub")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644))
+	t.Setenv(EnvConfigPublicKey, keyPath)
+
+	sign := func(path, contents string) {
+		signature := ed25519.Sign(privateKey, []byte(contents))
+		require.NoError(t, os.WriteFile(path+signatureSuffix, []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), 0o644))
+	}
+	sign("config/prod.applications.yml", policyTestConfig)
+	sign("config/prod.d/discovery.yml", "applications: []\n")
+
+	_, err = NewConfig("config/prod.applications.yml")
+	assert.NoError(t, err)
+
+	_, err = NewConfig("config/prod.d/discovery.yml")
+	assert.EqualError(t, err, "config file config/prod.d/discovery.yml signature does not verify")
+
+	require.NoError(t, os.Remove("config/prod.applications.yml"+signatureSuffix))
+	_, err = NewConfig("config/prod.applications.yml")
+	assert.ErrorContains(t, err, "config file config/prod.applications.yml has no signature")
+}
+
+func TestLoadPublicKey_NotPEM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.pub")
+	require.NoError(t, os.WriteFile(path, []byte("not a key"), 0o644))
+
+	_, err := loadPublicKey(path)
+
+	assert.EqualError(t, err, "config public key "+path+" is not PEM encoded")
+}
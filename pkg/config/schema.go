@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	a "github.com/NYULibraries/aswa/pkg/application"
+)
+
+// durationPattern matches the Go duration strings time.ParseDuration accepts, e.g. 600ms or 1m30s.
+const durationPattern = `^[-+]?(0|([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+$`
+
+// schemaEnums lists the allowed values of string settings, by struct type and yaml key.
+var schemaEnums = map[string][]string{
+	"Application.type":             {a.CheckHTTP, a.CheckDNS, a.CheckTCP},
+	"Application.csp_mode":         {a.CSPExact, a.CSPIncludes, a.CSPExcludes},
+	"Application.security_profile": {a.SecurityBaseline, a.SecurityStrict},
+	"Auth.type":                    {a.AuthBasic, a.AuthBearer, a.AuthAPIKey, a.AuthOAuth2},
+	"ContentAssertion.mode":        {a.ContentLiteral, a.ContentRegex, a.ContentCaseInsensitive, a.ContentMustNotContain},
+	"DriftCheck.mode":              {a.DriftAny, a.DriftSize},
+}
+
+// schemaBuilder derives JSON Schema definitions from the types the config decodes into.
+type schemaBuilder struct {
+	defs map[string]any
+}
+
+// Schema returns the JSON Schema of the config format, for editors and CI to validate config
+// files with. It is derived from the same types and yaml keys the config is decoded with.
+func Schema() ([]byte, error) {
+	b := &schemaBuilder{defs: map[string]any{}}
+	application := b.ref(applicationType)
+	schema := map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "ASWA config",
+		"description": "Applications checked by ASWA, with their defaults and groups.",
+		"type":        "object",
+		"properties": map[string]any{
+			"include":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"defaults": application,
+			"groups":   map[string]any{"type": "object", "additionalProperties": application},
+			"applications": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"allOf": []any{application, map[string]any{"required": []string{"name"}}}},
+			},
+		},
+		"additionalProperties": false,
+		"$defs":                b.defs,
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// schemaFor returns the schema of a value of type t. Types with a shorthand in YAML accept it too.
+func (b *schemaBuilder) schemaFor(t reflect.Type) map[string]any {
+	switch t {
+	case reflect.TypeFor[time.Duration]():
+		return map[string]any{"type": "string", "pattern": durationPattern}
+	case reflect.TypeFor[a.ContentAssertions]():
+		item := map[string]any{"oneOf": []any{map[string]any{"type": "string"}, b.ref(reflect.TypeFor[a.ContentAssertion]())}}
+		return map[string]any{"oneOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "array", "items": item}}}
+	case reflect.TypeFor[a.HeaderAssertion]():
+		return map[string]any{"oneOf": []any{map[string]any{"type": "string"}, b.ref(t)}}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		// null unsets an optional setting, e.g. one inherited from defaults.
+		return map[string]any{"anyOf": []any{b.schemaFor(t.Elem()), map[string]any{"type": "null"}}}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return b.ref(t)
+	}
+	return map[string]any{}
+}
+
+// ref returns a reference to the definition of a struct type, adding the definition on first use.
+// Unknown keys are not allowed, as in config validation.
+func (b *schemaBuilder) ref(t reflect.Type) map[string]any {
+	if _, ok := b.defs[t.Name()]; !ok {
+		b.defs[t.Name()] = nil // reserves the name while the fields are built
+		properties := map[string]any{}
+		for name, field := range yamlFields(t) {
+			property := b.schemaFor(field)
+			if enum, ok := schemaEnums[t.Name()+"."+name]; ok {
+				property["enum"] = enum
+			}
+			properties[name] = property
+		}
+		b.defs[t.Name()] = map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	}
+	return map[string]any{"$ref": "#/$defs/" + t.Name()}
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schemaPath = "../../schema/aswa.schema.json"
+
+// The published schema must match the config types; regenerate it with
+// `go run . validate -schema > schema/aswa.schema.json`.
+func TestSchema_MatchesPublished(t *testing.T) {
+	schema, err := Schema()
+	require.NoError(t, err)
+
+	published, err := os.ReadFile(schemaPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(published), string(schema))
+}
+
+func TestSchema(t *testing.T) {
+	data, err := Schema()
+	require.NoError(t, err)
+
+	var schema struct {
+		Defs map[string]struct {
+			Properties           map[string]map[string]any `json:"properties"`
+			AdditionalProperties bool                      `json:"additionalProperties"`
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	application := schema.Defs["Application"]
+	assert.False(t, application.AdditionalProperties, "unknown fields are rejected")
+	assert.Len(t, application.Properties, len(yamlFields(applicationType)))
+	assert.Equal(t, map[string]any{"type": "string", "pattern": durationPattern}, application.Properties["timeout"])
+	assert.Equal(t, []any{"http", "dns", "tcp"}, application.Properties["type"]["enum"])
+	assert.Contains(t, application.Properties["expected_content"], "oneOf", "a plain string or a list")
+	assert.Contains(t, schema.Defs, "Step")
+	assert.Contains(t, schema.Defs["Step"].Properties, "extract")
+}
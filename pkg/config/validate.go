@@ -0,0 +1,599 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	a "github.com/NYULibraries/aswa/pkg/application"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is one thing wrong with the config, located in the file that causes it.
+type Problem struct {
+	File        string
+	Line        int
+	Column      int
+	Application string // empty for problems outside an application, e.g. in defaults
+	Message     string
+}
+
+// String formats the problem as file:line:column: application "name": message.
+func (problem Problem) String() string {
+	location := problem.File
+	if problem.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", problem.File, problem.Line, problem.Column)
+	}
+	if problem.Application != "" {
+		return fmt.Sprintf("%s: application %q: %s", location, problem.Application, problem.Message)
+	}
+	return location + ": " + problem.Message
+}
+
+// ValidationError lists every problem found in the config, so they can all be fixed in one go.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (err *ValidationError) Error() string {
+	lines := []string{"config file is invalid:"}
+	for _, problem := range err.Problems {
+		lines = append(lines, problem.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// source is where an application is defined: its file and its node in that file. Once the
+// application is resolved, effective holds its settings with defaults and its group applied.
+type source struct {
+	file      string
+	node      *yaml.Node
+	defaults  *yaml.Node
+	effective *yaml.Node
+}
+
+// validator collects problems instead of stopping at the first one.
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) add(file string, node *yaml.Node, application, format string, args ...any) {
+	problem := Problem{File: file, Application: application, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		problem.Line, problem.Column = node.Line, node.Column
+	}
+	v.problems = append(v.problems, problem)
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+var (
+	applicationType = reflect.TypeFor[a.Application]()
+	rawConfigType   = reflect.TypeFor[rawConfig]()
+)
+
+// checkFile reports unknown fields and values of the wrong type in one file. It runs before files
+// are merged, so problems point at the file and line they are in.
+func (v *validator) checkFile(file configFile) {
+	root := documentContent(&file.root)
+	if root == nil || root.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "include":
+			v.checkNode(file.path, "", value, reflect.TypeFor[[]string]())
+		case "defaults":
+			v.checkNode(file.path, "", value, applicationType)
+		case "groups":
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				v.checkNode(file.path, "", value.Content[j+1], applicationType)
+			}
+		case "applications":
+			for _, node := range value.Content {
+				v.checkNode(file.path, applicationName(node), node, applicationType)
+			}
+		default:
+			v.add(file.path, key, "", "unknown field %q%s", key.Value, suggestion(key.Value, yamlFields(rawConfigType)))
+		}
+	}
+}
+
+// documentContent returns the top-level node of a parsed file, or nil for an empty file.
+func documentContent(document *yaml.Node) *yaml.Node {
+	if document.Kind == yaml.DocumentNode && len(document.Content) > 0 {
+		return document.Content[0]
+	}
+	return nil
+}
+
+// applicationName returns the name set in an application's own node, if any.
+func applicationName(node *yaml.Node) string {
+	if name := mappingValue(node, "name"); name != nil && name.Kind == yaml.ScalarNode {
+		return name.Value
+	}
+	return ""
+}
+
+// checkNode reports the unknown fields in node and the values that cannot be decoded into t.
+func (v *validator) checkNode(file, application string, node *yaml.Node, t reflect.Type) {
+	v.checkFields(file, application, node, t)
+	if err := node.Decode(reflect.New(t).Interface()); err != nil {
+		v.addDecodeError(file, application, node, err)
+	}
+}
+
+// checkFields walks node alongside the type it decodes into and reports mapping keys that no field
+// of a struct is named after, suggesting the closest field name.
+func (v *validator) checkFields(file, application string, node *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				v.add(file, key, application, "unknown field %q%s", key.Value, suggestion(key.Value, fields))
+				continue
+			}
+			v.checkFields(file, application, value, field)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for _, item := range node.Content {
+			v.checkFields(file, application, item, t.Elem())
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			v.checkFields(file, application, node.Content[i], t.Elem())
+		}
+	}
+	// Anything else is a scalar, or a shorthand such as a plain string for expected_content;
+	// decoding reports it if it is of the wrong type.
+}
+
+// yamlFields returns the types of a struct's fields by the keys they are decoded from.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for field := range t.Fields() {
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		switch {
+		case !field.IsExported() || name == "-":
+			continue
+		case options == "inline":
+			maps.Copy(fields, yamlFields(field.Type))
+			continue
+		case name == "":
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// suggestion returns ", did you mean x?" for the known field closest to an unknown one, if any is close.
+func suggestion(unknown string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", 3
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if distance := editDistance(strings.ToLower(unknown), name); distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %s?", best)
+}
+
+// editDistance is the Levenshtein distance between two strings.
+func editDistance(s, t string) int {
+	previous := make([]int, len(t)+1)
+	current := make([]int, len(t)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		current[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(t)]
+}
+
+// addDecodeError reports each message of a decoding error at the node on the line it names.
+func (v *validator) addDecodeError(file, application string, node *yaml.Node, err error) {
+	var typeErr *yaml.TypeError
+	messages := []string{err.Error()}
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+	for _, message := range messages {
+		at := node
+		if prefix, rest, found := strings.Cut(message, ": "); found && strings.HasPrefix(prefix, "line ") {
+			if line, err := strconv.Atoi(strings.TrimPrefix(prefix, "line ")); err == nil {
+				message = rest
+				if onLine := nodeOnLine(node, line); onLine != nil {
+					at = onLine
+				}
+			}
+		}
+		v.add(file, at, application, "%s", message)
+	}
+}
+
+// nodeOnLine returns the last node under node that starts on line, which for "key: value" is the value.
+func nodeOnLine(node *yaml.Node, line int) *yaml.Node {
+	var found *yaml.Node
+	if node.Line == line {
+		found = node
+	}
+	for _, child := range node.Content {
+		if onLine := nodeOnLine(child, line); onLine != nil {
+			found = onLine
+		}
+	}
+	return found
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	if i := mappingIndex(node, key); i >= 0 {
+		return node.Content[i+1]
+	}
+	return nil
+}
+
+// locate returns the node at a path such as "steps[1].extract.token.regex" in an application's
+// own node. When the setting is not there, e.g. because it comes from defaults or is missing,
+// it returns the closest enclosing node that is.
+func locate(node *yaml.Node, path string) *yaml.Node {
+	for segment := range strings.SplitSeq(path, ".") {
+		key, index, indexed := strings.Cut(strings.TrimSuffix(segment, "]"), "[")
+		next := mappingValue(node, key)
+		if next == nil {
+			return node
+		}
+		if indexed {
+			i, err := strconv.Atoi(index)
+			if err != nil || next.Kind != yaml.SequenceNode || i >= len(next.Content) {
+				return next
+			}
+			next = next.Content[i]
+		}
+		node = next
+	}
+	return node
+}
+
+// checker validates the effective settings of one application, reporting each problem at the
+// setting it concerns.
+type checker struct {
+	v      *validator
+	source source
+	name   string
+}
+
+func (c checker) at(path, format string, args ...any) {
+	c.v.add(c.source.file, locate(c.source.node, path), c.name, format, args...)
+}
+
+// validateApplication checks the settings of an application once defaults and its group are applied.
+func (v *validator) validateApplication(source source, app *a.Application) {
+	c := checker{v: v, source: source, name: app.Name}
+
+	for _, field := range missingRequiredFields(app) {
+		c.at(field, "missing required field %s", field)
+	}
+	if slices.Contains([]string{CommandValidate, CommandAcceptDrift}, app.Name) {
+		c.at("name", "name %q is reserved for the aswa %s command", app.Name, app.Name)
+	}
+
+	switch app.Type {
+	case "", a.CheckHTTP:
+	case a.CheckDNS, a.CheckTCP:
+		if app.URL != "" {
+			c.at("url", "url cannot be used with type %s", app.Type)
+		}
+	default:
+		c.oneOf("type", app.Type, a.CheckHTTP, a.CheckDNS, a.CheckTCP)
+	}
+	if len(app.Steps) > 0 && app.URL != "" {
+		c.at("url", "url cannot be used with steps, each step has its own url")
+	}
+
+	c.url("url", app.URL)
+	c.status("expected_status", app.ExpectedStatusCode)
+	c.nonNegativeDuration("timeout", app.Timeout)
+	c.nonNegativeDuration("retry_backoff", app.RetryBackoff)
+	c.nonNegativeDuration("max_response_time", app.MaxResponseTime)
+	c.nonNegativeDuration("warn_response_time", app.WarnResponseTime)
+	if app.WarnResponseTime > 0 && app.MaxResponseTime > 0 && app.WarnResponseTime >= app.MaxResponseTime {
+		c.at("warn_response_time", "warn_response_time %s must be less than max_response_time %s", app.WarnResponseTime, app.MaxResponseTime)
+	}
+	c.nonNegative("retries", app.Retries)
+	c.nonNegative("max_redirects", app.MaxRedirects)
+	c.nonNegative("min_cert_days_remaining", app.MinCertDaysRemaining)
+	if app.Body != "" && app.BodyFile != "" {
+		c.at("body_file", "body and body_file are mutually exclusive")
+	}
+
+	c.content("expected_content", app.ExpectedContent)
+	c.json("expected_json", app.ExpectedJSON)
+	c.html("expected_html", app.ExpectedHTML)
+	c.xml("expected_xml", app.ExpectedXML)
+	c.headers("expected_headers", app.ExpectedHeaders)
+	c.oneOf("csp_mode", app.CSPMode, a.CSPExact, a.CSPIncludes, a.CSPExcludes)
+	c.oneOf("security_profile", app.SecurityProfile, a.SecurityBaseline, a.SecurityStrict)
+	if app.ExpectedFinalURL != "" {
+		c.url("expected_final_url", app.ExpectedFinalURL)
+	}
+	for i, redirect := range app.ExpectedRedirects {
+		path := fmt.Sprintf("expected_redirects[%d]", i)
+		c.status(path+".status", redirect.Status)
+		if redirect.Location != "" && redirect.LocationRegex != "" {
+			c.at(path+".location_regex", "location and location_regex are mutually exclusive")
+		}
+		c.regex(path+".location_regex", redirect.LocationRegex)
+	}
+
+	if app.Auth != nil {
+		c.oneOf("auth.type", app.Auth.Type, a.AuthBasic, a.AuthBearer, a.AuthAPIKey, a.AuthOAuth2)
+		if app.Auth.Type == "" {
+			c.at("auth.type", "missing required field auth.type")
+		}
+		if app.Auth.TokenURL != "" {
+			c.url("auth.token_url", app.Auth.TokenURL)
+		}
+	}
+	if app.Drift != nil {
+		c.oneOf("drift.mode", app.Drift.Mode, a.DriftAny, a.DriftSize)
+		if app.Drift.SizeChangePercent < 0 {
+			c.at("drift.size_change_percent", "drift.size_change_percent must not be negative")
+		}
+	}
+	if app.DNS != nil {
+		for i, record := range app.DNS.Records {
+			path := fmt.Sprintf("dns.records[%d]", i)
+			if !slices.Contains([]string{"IP", "A", "AAAA", "CNAME", "TXT", "MX"}, strings.ToUpper(record.Type)) {
+				c.at(path+".type", "record type must be one of IP, A, AAAA, CNAME, TXT or MX, got %q", record.Type)
+			}
+			c.regex(path+".regex", record.Regex)
+		}
+	}
+	if app.TCP != nil {
+		if _, _, err := net.SplitHostPort(app.TCP.Address); app.TCP.Address != "" && err != nil {
+			c.at("tcp.address", "tcp.address %q must be host:port", app.TCP.Address)
+		}
+		c.nonNegativeDuration("tcp.connect_timeout", app.TCP.ConnectTimeout)
+		c.regex("tcp.expect", app.TCP.Expect)
+	}
+
+	extracted := map[string]bool{}
+	for i, step := range app.Steps {
+		path := fmt.Sprintf("steps[%d]", i)
+		c.stepReferences(path+".url", step.URL, extracted)
+		for _, name := range slices.Sorted(maps.Keys(step.Headers)) {
+			c.stepReferences(path+".headers."+name, step.Headers[name], extracted)
+		}
+		c.stepReferences(path+".body", step.Body, extracted)
+		for _, name := range slices.Sorted(maps.Keys(step.BodyForm)) {
+			c.stepReferences(path+".body_form."+name, step.BodyForm[name], extracted)
+		}
+		c.url(path+".url", step.URL)
+		if strings.Contains(step.URL, "${secret:") {
+			c.at(path+".url", "secrets cannot be used in url, it is printed in the output; send them in headers, body or body_form")
+		}
+		if step.Body != "" && len(step.BodyForm) > 0 {
+			c.at(path+".body_form", "body and body_form are mutually exclusive")
+		}
+		c.status(path+".expected_status", step.ExpectedStatusCode)
+		c.content(path+".expected_content", step.ExpectedContent)
+		c.json(path+".expected_json", step.ExpectedJSON)
+		c.html(path+".expected_html", step.ExpectedHTML)
+		c.xml(path+".expected_xml", step.ExpectedXML)
+		c.headers(path+".expected_headers", step.ExpectedHeaders)
+		for _, name := range slices.Sorted(maps.Keys(step.Extract)) {
+			extraction := step.Extract[name]
+			extractPath := path + ".extract." + name
+			if countSet(extraction.Regex != "", extraction.JSON != "", extraction.Header != "") != 1 {
+				c.at(extractPath, "extraction %q needs exactly one of regex, json or header", name)
+			}
+			c.regex(extractPath+".regex", extraction.Regex)
+			extracted[name] = true
+		}
+	}
+}
+
+// stepReferences reports the ${name} references in a step's value to names no earlier step extracts.
+func (c checker) stepReferences(path, value string, extracted map[string]bool) {
+	for _, name := range a.VariableReferences(value) {
+		if !extracted[name] {
+			c.at(path, "${%s} is not extracted by an earlier step", name)
+		}
+	}
+}
+
+// missingRequiredFields returns the required settings an application lacks. DNS and TCP checks
+// need a name to resolve or an address to connect to instead of a URL and expected status, and
+// multi-step checks need a URL for every step.
+func missingRequiredFields(app *a.Application) []string {
+	var missing []string
+	if app.Name == "" {
+		missing = append(missing, "name")
+	}
+	switch {
+	case app.Type == a.CheckDNS:
+		if app.DNS == nil || app.DNS.Name == "" {
+			missing = append(missing, "dns.name")
+		}
+	case app.Type == a.CheckTCP:
+		if app.TCP == nil || app.TCP.Address == "" {
+			missing = append(missing, "tcp.address")
+		}
+	case len(app.Steps) > 0:
+		for i, step := range app.Steps {
+			if step.URL == "" {
+				missing = append(missing, fmt.Sprintf("steps[%d].url", i))
+			}
+		}
+	default:
+		if app.URL == "" {
+			missing = append(missing, "url")
+		}
+		if app.ExpectedStatusCode == 0 {
+			missing = append(missing, "expected_status")
+		}
+	}
+	return missing
+}
+
+// url checks that a set URL is absolute http or https.
+func (c checker) url(path, value string) {
+	if value == "" {
+		return
+	}
+	parsed, err := url.Parse(value)
+	switch {
+	case err != nil:
+		c.at(path, "%s %q is not a valid URL", path, value)
+	case parsed.Scheme != "http" && parsed.Scheme != "https":
+		c.at(path, "%s %q must be an http or https URL", path, value)
+	case parsed.Host == "":
+		c.at(path, "%s %q has no host", path, value)
+	}
+}
+
+func (c checker) status(path string, status int) {
+	if status != 0 && (status < 100 || status > 599) {
+		c.at(path, "%s %d is not an HTTP status code", path, status)
+	}
+}
+
+func (c checker) nonNegative(path string, value int) {
+	if value < 0 {
+		c.at(path, "%s must not be negative", path)
+	}
+}
+
+func (c checker) nonNegativeDuration(path string, value time.Duration) {
+	if value < 0 {
+		c.at(path, "%s must not be negative", path)
+	}
+}
+
+// oneOf checks that a set value is one of the allowed ones.
+func (c checker) oneOf(path, value string, allowed ...string) {
+	if value != "" && !slices.Contains(allowed, value) {
+		c.at(path, "%s must be one of %s, got %q", path, strings.Join(allowed, ", "), value)
+	}
+}
+
+func (c checker) regex(path, pattern string) {
+	if pattern == "" {
+		return
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		c.at(path, "%s is not a valid regular expression: %v", path, err)
+	}
+}
+
+func (c checker) content(path string, assertions a.ContentAssertions) {
+	for i, assertion := range assertions {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		c.oneOf(itemPath+".mode", assertion.Mode, a.ContentLiteral, a.ContentRegex, a.ContentCaseInsensitive, a.ContentMustNotContain)
+		if assertion.Mode == a.ContentRegex {
+			c.regex(itemPath+".value", assertion.Value)
+		}
+	}
+}
+
+func (c checker) json(path string, assertions []a.JSONAssertion) {
+	for i, assertion := range assertions {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if err := a.ValidateJSONPath(assertion.Path); err != nil {
+			c.at(itemPath+".path", "%s.path is not valid: %v", itemPath, err)
+		}
+		if assertion.Regex != nil {
+			c.regex(itemPath+".regex", *assertion.Regex)
+		}
+		comparesValues := assertion.Equals != nil || assertion.Contains != nil || assertion.Regex != nil ||
+			assertion.Gt != nil || assertion.Gte != nil || assertion.Lt != nil || assertion.Lte != nil
+		if assertion.Exists != nil && !*assertion.Exists && comparesValues {
+			c.at(itemPath+".exists", "exists: false cannot be combined with a comparison of the selected values")
+		}
+	}
+}
+
+func (c checker) html(path string, assertions []a.HTMLAssertion) {
+	for i, assertion := range assertions {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case assertion.CSS != "" && assertion.XPath != "":
+			c.at(itemPath+".xpath", "css and xpath are mutually exclusive")
+		case assertion.CSS == "" && assertion.XPath == "":
+			c.at(itemPath, "needs a css or xpath selector")
+		}
+		if assertion.AttributeMatches != nil {
+			c.regex(itemPath+".attribute_matches", *assertion.AttributeMatches)
+		}
+	}
+}
+
+func (c checker) xml(path string, assertions []a.XMLAssertion) {
+	for i, assertion := range assertions {
+		if assertion.Regex != nil {
+			c.regex(fmt.Sprintf("%s[%d].regex", path, i), *assertion.Regex)
+		}
+	}
+}
+
+func (c checker) headers(path string, assertions map[string]a.HeaderAssertion) {
+	for _, name := range slices.Sorted(maps.Keys(assertions)) {
+		assertion := assertions[name]
+		headerPath := path + "." + name
+		if countSet(assertion.Equals != nil, assertion.Regex != "", assertion.Present != nil) > 1 {
+			c.at(headerPath, "header %s: equals, regex and present are mutually exclusive", name)
+		}
+		c.regex(headerPath+".regex", assertion.Regex)
+	}
+}
+
+// countSet returns how many of a set of mutually exclusive options are set.
+func countSet(options ...bool) int {
+	set := 0
+	for _, option := range options {
+		if option {
+			set++
+		}
+	}
+	return set
+}
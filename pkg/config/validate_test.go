@@ -0,0 +1,233 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestNewConfig_ReportsAllProblems(t *testing.T) {
+	path := writeConfig(t, `
+defaults:
+  timout: 5s
+applications:
+  - name: library
+    url: 'library.nyu.edu'
+    expected_status: 2000
+    expectedLocation: 'https://library.nyu.edu/'
+  - name: bobcat
+    url: 'https://bobcat.library.nyu.edu'
+    expected_status: 200
+    timeout: 600
+    max_response_time: 1s
+    warn_response_time: 2s
+    expected_content:
+      - mode: regex
+        value: 'Bobcat ('
+    expected_headers:
+      Cache-Control:
+        equals: no-store
+        present: true
+  - name: z3950
+    type: tcp
+    url: 'https://aleph.library.nyu.edu'
+    tcp:
+      address: aleph.library.nyu.edu
+  - name: illiad
+    steps:
+      - url: 'https://ill.library.nyu.edu/illiad/logon.html'
+        extract:
+          token:
+            regex: 'name="token" value="([^"]+)"'
+            header: X-Token
+      - name: home
+`)
+
+	_, err := NewConfig(path)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	var problems []string
+	for _, problem := range validationErr.Problems {
+		problems = append(problems, problem.String())
+	}
+	assert.Equal(t, []string{
+		path + `:3:3: unknown field "timout", did you mean timeout?`,
+		path + `:8:5: application "library": unknown field "expectedLocation", did you mean expected_location?`,
+		path + `:12:14: application "bobcat": cannot unmarshal !!int ` + "`600`" + ` into time.Duration`,
+		path + `:6:10: application "library": url "library.nyu.edu" must be an http or https URL`,
+		path + `:7:22: application "library": expected_status 2000 is not an HTTP status code`,
+		path + `:24:10: application "z3950": url cannot be used with type tcp`,
+		path + `:26:16: application "z3950": tcp.address "aleph.library.nyu.edu" must be host:port`,
+		path + `:34:9: application "illiad": missing required field steps[1].url`,
+		path + `:32:13: application "illiad": extraction "token" needs exactly one of regex, json or header`,
+	}, problems, "bobcat is not validated further, since its timeout cannot be decoded")
+	assert.ErrorContains(t, err, "config file is invalid:\n"+path+`:3:3: unknown field "timout"`)
+}
+
+func TestNewConfig_SemanticProblems(t *testing.T) {
+	path := writeConfig(t, `
+applications:
+  - name: bobcat
+    url: 'https://bobcat.library.nyu.edu'
+    expected_status: 200
+    max_response_time: 1s
+    warn_response_time: 2s
+    retries: -1
+    body: 'q=test'
+    body_file: 'config/bodies/search.txt'
+    csp_mode: strict
+    expected_content:
+      - mode: regex
+        value: 'Bobcat ('
+    expected_html:
+      - css: 'title'
+        xpath: '//title'
+    expected_headers:
+      Cache-Control:
+        equals: no-store
+        present: true
+    expected_redirects:
+      - status: 301
+        location: 'https://bobcat.library.nyu.edu/'
+        location_regex: '^https://'
+    drift:
+      mode: hash
+  - name: library
+    type: ftp
+  - name: illiad-login
+    steps:
+      - url: 'https://ill.library.nyu.edu/logon?p=${secret:env:ILLIAD_PASSWORD}'
+        body: 'username=aswa'
+        body_form:
+          token: x
+  - name: status-page
+    url: 'https://status.redhat.com/api/v2/components.json'
+    expected_status: 200
+    expected_json:
+      - path: 'components[0]'
+      - path: '$.page.incident'
+        exists: false
+        equals: none
+  - name: illiad-home
+    steps:
+      - url: 'https://ill.library.nyu.edu/illiad/logon.html'
+        headers:
+          X-Session: '${session}'
+        extract:
+          token:
+            regex: 'name="token" value="([^"]+)"'
+      - url: 'https://ill.library.nyu.edu/illiad/main.html?t=${token}&u=${user}'
+`)
+
+	_, err := NewConfig(path)
+
+	require.Error(t, err)
+	assert.Equal(t, "config file is invalid:\n"+
+		path+`:7:25: application "bobcat": warn_response_time 2s must be less than max_response_time 1s`+"\n"+
+		path+`:8:14: application "bobcat": retries must not be negative`+"\n"+
+		path+`:10:16: application "bobcat": body and body_file are mutually exclusive`+"\n"+
+		path+`:14:16: application "bobcat": expected_content[0].value is not a valid regular expression: error parsing regexp: missing closing ): `+"`Bobcat (`\n"+
+		path+`:17:16: application "bobcat": css and xpath are mutually exclusive`+"\n"+
+		path+`:20:9: application "bobcat": header Cache-Control: equals, regex and present are mutually exclusive`+"\n"+
+		path+`:11:15: application "bobcat": csp_mode must be one of exact, includes, excludes, got "strict"`+"\n"+
+		path+`:25:25: application "bobcat": location and location_regex are mutually exclusive`+"\n"+
+		path+`:27:13: application "bobcat": drift.mode must be one of any, size, got "hash"`+"\n"+
+		path+`:28:5: application "library": missing required field url`+"\n"+
+		path+`:28:5: application "library": missing required field expected_status`+"\n"+
+		path+`:29:11: application "library": type must be one of http, dns, tcp, got "ftp"`+"\n"+
+		path+`:32:14: application "illiad-login": secrets cannot be used in url, it is printed in the output; send them in headers, body or body_form`+"\n"+
+		path+`:35:11: application "illiad-login": body and body_form are mutually exclusive`+"\n"+
+		path+`:40:15: application "status-page": expected_json[0].path is not valid: JSONPath "components[0]" must start with $, . or [`+"\n"+
+		path+`:42:17: application "status-page": exists: false cannot be combined with a comparison of the selected values`+"\n"+
+		path+`:48:22: application "illiad-home": ${session} is not extracted by an earlier step`+"\n"+
+		path+`:52:14: application "illiad-home": ${user} is not extracted by an earlier step`,
+		err.Error())
+}
+
+// Problems in one file of several point at that file, and settings inherited from defaults are
+// validated once they apply to an application, only in the file that declares them.
+func TestNewConfig_ReservedNames(t *testing.T) {
+	path := writeConfig(t, `
+applications:
+  - name: validate
+    url: 'https://library.nyu.edu/validate'
+    expected_status: 200
+  - name: accept-drift
+    url: 'https://library.nyu.edu/accept-drift'
+    expected_status: 200
+`)
+
+	_, err := NewConfig(path)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	var problems []string
+	for _, problem := range validationErr.Problems {
+		problems = append(problems, problem.String())
+	}
+	assert.Equal(t, []string{
+		path + `:3:11: application "validate": name "validate" is reserved for the aswa validate command`,
+		path + `:6:11: application "accept-drift": name "accept-drift" is reserved for the aswa accept-drift command`,
+	}, problems)
+}
+
+func TestNewConfig_ProblemsAcrossFiles(t *testing.T) {
+	t.Setenv(EnvSkipWhitelistCheck, "true")
+	dir := writeConfigDir(t, map[string]string{
+		"prod.d/a.yml": "defaults:\n  retries: -1\napplications:" + application("bess"),
+		"prod.d/b.yml": "applications:\n  - name: primo\n    url: 'https://search.library.nyu.edu'\n    expected_status: 200\n    hedaers: {}\n",
+	})
+
+	_, err := NewConfig(filepath.Join(dir, "prod.d"))
+
+	require.Error(t, err)
+	assert.Equal(t, "config file is invalid:\n"+
+		filepath.Join(dir, "prod.d", "b.yml")+`:5:5: application "primo": unknown field "hedaers", did you mean headers?`+"\n"+
+		filepath.Join(dir, "prod.d", "a.yml")+`:4:5: application "bess": retries must not be negative`,
+		err.Error())
+}
+
+func TestLocate(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`name: illiad
+steps:
+  - url: 'https://ill.library.nyu.edu/illiad/logon.html'
+  - url: 'https://ill.library.nyu.edu/illiad/home.html'
+    extract:
+      token:
+        regex: '('
+`), &root))
+	node := root.Content[0]
+
+	var tests = []struct {
+		path   string
+		line   int
+		column int
+	}{
+		{"name", 1, 7},
+		{"steps[1].extract.token.regex", 7, 16},
+		{"steps[1].extract.session", 6, 7},
+		{"steps[5].url", 3, 3},
+		{"timeout", 1, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			located := locate(node, test.path)
+			assert.Equal(t, test.line, located.Line)
+			assert.Equal(t, test.column, located.Column)
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("timeout", "timeout"))
+	assert.Equal(t, 1, editDistance("timout", "timeout"))
+	assert.Equal(t, 2, editDistance("expectedLocation", "expected_location"))
+	assert.Equal(t, 7, editDistance("", "timeout"))
+}